@@ -39,6 +39,10 @@ func Contains(val cty.Value, mark valueMark) bool {
 // Terraform.
 const Sensitive = valueMark("Sensitive")
 
+// Ephemeral indicates that this value lives only for the duration of a single
+// phase of an operation and is never persisted between phases.
+const Ephemeral = valueMark("Ephemeral")
+
 // TypeType is used to indicate that the value contains a representation of
 // another value's type. This is part of the implementation of the console-only
 // `type` function.
@@ -0,0 +1,112 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package mocking
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+// CanonicalValueString renders a value as a string suitable for golden files
+// and other snapshot-style comparisons.
+//
+// The native GoString rendering of a value containing sets can differ between
+// processes, because sets have no inherent order. This rendering sorts set
+// elements by their own canonical rendering, and map and object keys
+// lexically, so equal values always render identically. The set semantics of
+// the value itself are unaffected; this only changes how it is printed.
+//
+// Marks are stripped before rendering, since their print order is also
+// unspecified.
+func CanonicalValueString(value cty.Value) string {
+	value, _ = value.UnmarkDeep()
+	return canonicalString(value)
+}
+
+// setOrderings collects the canonical element ordering of every set within
+// the given value, keyed by the set's path as rendered by fmtPath. The
+// orderings match how CanonicalValueString renders the same sets.
+func setOrderings(value cty.Value) map[string][]string {
+	orderings := make(map[string][]string)
+
+	value, _ = value.UnmarkDeep()
+	cty.Walk(value, func(path cty.Path, value cty.Value) (bool, error) {
+		if value.IsNull() || !value.IsKnown() || !value.Type().IsSetType() {
+			return true, nil
+		}
+
+		var elems []string
+		for it := value.ElementIterator(); it.Next(); {
+			_, elem := it.Element()
+			elems = append(elems, canonicalString(elem))
+		}
+		sort.Strings(elems)
+		orderings[fmtPath(path)] = elems
+		return true, nil
+	})
+
+	if len(orderings) == 0 {
+		return nil
+	}
+	return orderings
+}
+
+func canonicalString(value cty.Value) string {
+	if value == cty.NilVal {
+		return value.GoString()
+	}
+
+	// Values arrive here as-is from the distinctness and derived-bool
+	// checks, and a marked collection can't be iterated, so strip any marks
+	// before rendering. Equal values render identically whether marked or
+	// not.
+	value, _ = value.UnmarkDeep()
+
+	if value.IsNull() || !value.IsKnown() {
+		return value.GoString()
+	}
+
+	ty := value.Type()
+	switch {
+	case ty.IsPrimitiveType():
+		return value.GoString()
+
+	case ty.IsSetType():
+		var elems []string
+		for it := value.ElementIterator(); it.Next(); {
+			_, elem := it.Element()
+			elems = append(elems, canonicalString(elem))
+		}
+		sort.Strings(elems)
+		return fmt.Sprintf("set(%s)", strings.Join(elems, ", "))
+
+	case ty.IsListType(), ty.IsTupleType():
+		var elems []string
+		for it := value.ElementIterator(); it.Next(); {
+			_, elem := it.Element()
+			elems = append(elems, canonicalString(elem))
+		}
+		return fmt.Sprintf("[%s]", strings.Join(elems, ", "))
+
+	case ty.IsMapType(), ty.IsObjectType():
+		values := value.AsValueMap()
+		keys := make([]string, 0, len(values))
+		for key := range values {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		var elems []string
+		for _, key := range keys {
+			elems = append(elems, fmt.Sprintf("%s: %s", key, canonicalString(values[key])))
+		}
+		return fmt.Sprintf("{%s}", strings.Join(elems, ", "))
+
+	default:
+		return value.GoString()
+	}
+}
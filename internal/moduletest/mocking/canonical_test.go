@@ -0,0 +1,44 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package mocking
+
+import (
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestCanonicalValueString(t *testing.T) {
+	// The same logical value built with different element orderings must
+	// render identically.
+	first := cty.ObjectVal(map[string]cty.Value{
+		"block": cty.SetVal([]cty.Value{
+			cty.ObjectVal(map[string]cty.Value{"id": cty.StringVal("one")}),
+			cty.ObjectVal(map[string]cty.Value{"id": cty.StringVal("two")}),
+		}),
+	})
+	second := cty.ObjectVal(map[string]cty.Value{
+		"block": cty.SetVal([]cty.Value{
+			cty.ObjectVal(map[string]cty.Value{"id": cty.StringVal("two")}),
+			cty.ObjectVal(map[string]cty.Value{"id": cty.StringVal("one")}),
+		}),
+	})
+
+	if CanonicalValueString(first) != CanonicalValueString(second) {
+		t.Errorf("expected identical renderings\nfirst:  %s\nsecond: %s", CanonicalValueString(first), CanonicalValueString(second))
+	}
+
+	expected := `{block: set({id: cty.StringVal("one")}, {id: cty.StringVal("two")})}`
+	if actual := CanonicalValueString(first); actual != expected {
+		t.Errorf("unexpected rendering\nexpected: %s\nactual:   %s", expected, actual)
+	}
+
+	// Marked values render the same as their unmarked equivalents.
+	marked := cty.ObjectVal(map[string]cty.Value{
+		"block": first.GetAttr("block").Mark("sensitive"),
+	})
+	if CanonicalValueString(marked) != CanonicalValueString(first) {
+		t.Errorf("expected marks to be ignored in the rendering")
+	}
+}
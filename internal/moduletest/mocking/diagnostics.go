@@ -0,0 +1,85 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package mocking
+
+import (
+	"github.com/hashicorp/terraform/internal/tfdiags"
+)
+
+// DiagnosticCause classifies a generation diagnostic by where the problem
+// originated, so UIs can present mistakes in user-supplied overrides
+// differently from limitations of the generator itself.
+type DiagnosticCause int
+
+const (
+	// CauseGenerator marks diagnostics produced by limits or failures of
+	// the generator itself: schemas too deep or too large to mock, values
+	// it cannot convert, and so on. Diagnostics with no explicit
+	// classification fall into this bucket.
+	CauseGenerator DiagnosticCause = iota
+
+	// CauseOverride marks diagnostics caused by a problem with the
+	// override values the user supplied, like an override of the wrong
+	// type or missing overrides the options required.
+	CauseOverride
+)
+
+// DiagnosticWithCause is implemented by the extra info of diagnostics that
+// carry an explicit cause classification. Retrieve it with
+// tfdiags.ExtraInfo[DiagnosticWithCause], or just use DiagnosticCauseOf.
+type DiagnosticWithCause interface {
+	DiagnosticCause() DiagnosticCause
+}
+
+// DiagnosticCauseOf returns the cause classification of the given
+// diagnostic, defaulting to CauseGenerator for diagnostics that carry no
+// explicit classification.
+func DiagnosticCauseOf(diag tfdiags.Diagnostic) DiagnosticCause {
+	if extra := tfdiags.ExtraInfo[DiagnosticWithCause](diag); extra != nil {
+		return extra.DiagnosticCause()
+	}
+	return CauseGenerator
+}
+
+// diagnosticCauseExtra carries a DiagnosticCause as diagnostic extra info,
+// wrapping whatever extra info the diagnostic already had.
+type diagnosticCauseExtra struct {
+	cause   DiagnosticCause
+	wrapped interface{}
+}
+
+var (
+	_ DiagnosticWithCause              = (*diagnosticCauseExtra)(nil)
+	_ tfdiags.DiagnosticExtraWrapper   = (*diagnosticCauseExtra)(nil)
+	_ tfdiags.DiagnosticExtraUnwrapper = (*diagnosticCauseExtra)(nil)
+)
+
+func (extra *diagnosticCauseExtra) DiagnosticCause() DiagnosticCause {
+	return extra.cause
+}
+
+func (extra *diagnosticCauseExtra) WrapDiagnosticExtra(inner interface{}) {
+	extra.wrapped = inner
+}
+
+func (extra *diagnosticCauseExtra) UnwrapDiagnosticExtra() interface{} {
+	return extra.wrapped
+}
+
+// withCause wraps a single diagnostic with an explicit cause
+// classification, preserving its severity and everything else about it.
+func withCause(diag tfdiags.Diagnostic, cause DiagnosticCause) tfdiags.Diagnostic {
+	return tfdiags.Override(diag, diag.Severity(), func() tfdiags.DiagnosticExtraWrapper {
+		return &diagnosticCauseExtra{cause: cause}
+	})
+}
+
+// withCauseAll applies withCause to every diagnostic in the set.
+func withCauseAll(diags tfdiags.Diagnostics, cause DiagnosticCause) tfdiags.Diagnostics {
+	var out tfdiags.Diagnostics
+	for _, diag := range diags {
+		out = out.Append(withCause(diag, cause))
+	}
+	return out
+}
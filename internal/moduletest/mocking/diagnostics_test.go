@@ -0,0 +1,72 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package mocking
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/hashicorp/terraform/internal/configs/configschema"
+)
+
+func TestDiagnosticCauseOf(t *testing.T) {
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"id": {
+				Type:     cty.String,
+				Computed: true,
+			},
+		},
+	}
+
+	target := cty.ObjectVal(map[string]cty.Value{
+		"id": cty.NullVal(cty.String),
+	})
+
+	t.Run("override", func(t *testing.T) {
+		testRand = rand.New(rand.NewSource(0))
+		defer func() {
+			testRand = nil
+		}()
+
+		// The override supplies a list for a string attribute, which is a
+		// user mistake rather than a generator limitation.
+		with := ReplacementValue{
+			Value: cty.ObjectVal(map[string]cty.Value{
+				"id": cty.ListValEmpty(cty.String),
+			}),
+		}
+
+		_, diags := ComputedValuesForDataSource(target, with, schema)
+		if !diags.HasErrors() {
+			t.Fatalf("expected a type mismatch error, but got none")
+		}
+		if cause := DiagnosticCauseOf(diags[0]); cause != CauseOverride {
+			t.Errorf("expected the type mismatch to be classified as CauseOverride, got %v", cause)
+		}
+	})
+
+	t.Run("generator", func(t *testing.T) {
+		testRand = rand.New(rand.NewSource(0))
+		defer func() {
+			testRand = nil
+		}()
+
+		// Asking for a conversion the generated value can't satisfy is a
+		// generator-side failure, so it keeps the default classification.
+		generator := NewValueGenerator(GenerateOptions{
+			TargetType: cty.Map(cty.Bool),
+		})
+
+		_, diags := generator.ComputedValuesForDataSource(target, ReplacementValue{}, schema)
+		if !diags.HasErrors() {
+			t.Fatalf("expected a conversion error, but got none")
+		}
+		if cause := DiagnosticCauseOf(diags[0]); cause != CauseGenerator {
+			t.Errorf("expected the conversion failure to be classified as CauseGenerator, got %v", cause)
+		}
+	})
+}
@@ -0,0 +1,133 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package mocking
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+// stringForName generates a random string value appropriate for an attribute
+// with the given name.
+//
+// Most attributes just receive a short random alphanumeric string, but some
+// attribute names strongly suggest a specific format (email addresses,
+// hostnames, and so on). Providers frequently validate these formats, so a
+// plain random token would make the mocked value useless to any module that
+// feeds it into another resource. For those names we generate a random value
+// that still satisfies basic format checks.
+//
+// The generated values draw from the same random source as plain strings, so
+// seeding testRand makes them deterministic in the same way.
+func stringForName(source RandSource, name string) string {
+	switch {
+	case nameSuggestsFormat(name, "email"):
+		return generateEmail(source)
+	case nameSuggestsFormat(name, "hostname"), nameSuggestsFormat(name, "host"):
+		return generateHostname(source)
+	case nameSuggestsFormat(name, "json"), nameSuggestsFormat(name, "policy"):
+		return generateJSON(source)
+	case nameSuggestsFormat(name, "cidr"), nameSuggestsFormat(name, "cidr_block"):
+		return generateCIDR(source)
+	default:
+		return strFrom(source, 8)
+	}
+}
+
+// nameSuggestsFormat returns true if the given attribute name indicates that
+// values of the attribute should use the given named format. We match the
+// format name exactly, or as a suffix following an underscore (for names like
+// "admin_email").
+func nameSuggestsFormat(name, format string) bool {
+	name = strings.ToLower(name)
+	return name == format || strings.HasSuffix(name, "_"+format)
+}
+
+// generateEmail returns a random but well-formed email address.
+func generateEmail(source RandSource) string {
+	return fmt.Sprintf("%s@%s.com", strFrom(source, 8), strFrom(source, 8))
+}
+
+// generateHostname returns a random but well-formed DNS hostname.
+func generateHostname(source RandSource) string {
+	return fmt.Sprintf("%s.%s.com", strFrom(source, 8), strFrom(source, 8))
+}
+
+// generateJSON returns a small but valid JSON object as a string, for
+// attributes like "policy" that providers expect to hold JSON documents
+// rather than arbitrary tokens.
+func generateJSON(source RandSource) string {
+	return fmt.Sprintf(`{"mock":%q}`, strFrom(source, 8))
+}
+
+// generateCIDR returns a random but valid IPv4 CIDR block. The network is
+// drawn from the 10.0.0.0/8 private range with a /24 prefix, so the result
+// always parses and never names a routable network.
+func generateCIDR(source RandSource) string {
+	return fmt.Sprintf("10.%d.%d.0/24", source.Intn(256), source.Intn(256))
+}
+
+// nameSuggestsTimestamp returns true if the given attribute name indicates
+// the attribute holds a timestamp, like "created_at" or "expiry_time".
+// Timestamps are generated from a clock rather than the random source, so
+// they have their own check instead of going through nameSuggestsFormat.
+func nameSuggestsTimestamp(name string) bool {
+	name = strings.ToLower(name)
+	return name == "timestamp" || strings.HasSuffix(name, "_at") || strings.HasSuffix(name, "_time")
+}
+
+// generateUUID returns a random UUID-shaped string. The characters are drawn
+// from the usual alphanumeric set rather than strictly hexadecimal, which is
+// close enough for anything that only checks the shape.
+func generateUUID(source RandSource) string {
+	return fmt.Sprintf("%s-%s-%s-%s-%s", strFrom(source, 8), strFrom(source, 4), strFrom(source, 4), strFrom(source, 4), strFrom(source, 12))
+}
+
+// descriptionHintRegexp matches format hints like "format: uuid" anywhere in
+// an attribute description.
+var descriptionHintRegexp = regexp.MustCompile(`(?i)format:\s*([a-z0-9_]+)`)
+
+// descriptionHint extracts a format hint from an attribute description, or
+// returns an empty string if the description doesn't contain one.
+func descriptionHint(description string) string {
+	match := descriptionHintRegexp.FindStringSubmatch(description)
+	if match == nil {
+		return ""
+	}
+	return strings.ToLower(match[1])
+}
+
+// generateForHint generates a string in the format named by the given hint,
+// returning false if we don't recognise the hint.
+func generateForHint(source RandSource, hint string) (string, bool) {
+	switch hint {
+	case "email":
+		return generateEmail(source), true
+	case "hostname":
+		return generateHostname(source), true
+	case "json":
+		return generateJSON(source), true
+	case "uuid":
+		return generateUUID(source), true
+	case "cidr":
+		return generateCIDR(source), true
+	default:
+		return "", false
+	}
+}
+
+// pathBaseName returns the name of the attribute the given path points at, or
+// an empty string if the path is empty. Index steps are ignored, so elements
+// of a collection use the name of the collection attribute itself.
+func pathBaseName(path cty.Path) string {
+	for ix := len(path) - 1; ix >= 0; ix-- {
+		if step, ok := path[ix].(cty.GetAttrStep); ok {
+			return step.Name
+		}
+	}
+	return ""
+}
@@ -0,0 +1,278 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package mocking
+
+import (
+	"encoding/json"
+	"math/rand"
+	"net"
+	"net/mail"
+	"regexp"
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/hashicorp/terraform/internal/configs/configschema"
+)
+
+var hostnameRegexp = regexp.MustCompile(`^([a-z0-9]([a-z0-9-]*[a-z0-9])?\.)+[a-z0-9]([a-z0-9-]*[a-z0-9])?$`)
+
+func TestComputedValuesForDataSource_formats(t *testing.T) {
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"email": {
+				Type:     cty.String,
+				Computed: true,
+			},
+			"admin_email": {
+				Type:     cty.String,
+				Computed: true,
+			},
+			"hostname": {
+				Type:     cty.String,
+				Computed: true,
+			},
+			"id": {
+				Type:     cty.String,
+				Computed: true,
+			},
+		},
+	}
+
+	target := cty.ObjectVal(map[string]cty.Value{
+		"email":       cty.NullVal(cty.String),
+		"admin_email": cty.NullVal(cty.String),
+		"hostname":    cty.NullVal(cty.String),
+		"id":          cty.NullVal(cty.String),
+	})
+
+	testRand = rand.New(rand.NewSource(0))
+	defer func() {
+		testRand = nil
+	}()
+
+	value, diags := ComputedValuesForDataSource(target, ReplacementValue{}, schema)
+	if len(diags) > 0 {
+		t.Fatalf("unexpected diagnostics: %v", diags.ErrWithWarnings())
+	}
+
+	for _, attr := range []string{"email", "admin_email"} {
+		email := value.GetAttr(attr).AsString()
+		if _, err := mail.ParseAddress(email); err != nil {
+			t.Errorf("generated %s %q is not a valid email address: %s", attr, email, err)
+		}
+	}
+
+	hostname := value.GetAttr("hostname").AsString()
+	if !hostnameRegexp.MatchString(hostname) {
+		t.Errorf("generated hostname %q is not a valid hostname", hostname)
+	}
+
+	// Attributes without a recognised format should still get the plain
+	// random string treatment.
+	if id := value.GetAttr("id").AsString(); len(id) != 8 {
+		t.Errorf("expected plain 8 character string for id, got %q", id)
+	}
+
+	// The formatted values should be deterministic under a fixed seed. We
+	// check this with a single attribute schema because the order in which
+	// sibling attributes consume the random source is unspecified.
+	singleSchema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"email": {
+				Type:     cty.String,
+				Computed: true,
+			},
+		},
+	}
+	singleTarget := cty.ObjectVal(map[string]cty.Value{
+		"email": cty.NullVal(cty.String),
+	})
+
+	testRand = rand.New(rand.NewSource(0))
+	first, _ := ComputedValuesForDataSource(singleTarget, ReplacementValue{}, singleSchema)
+	testRand = rand.New(rand.NewSource(0))
+	second, _ := ComputedValuesForDataSource(singleTarget, ReplacementValue{}, singleSchema)
+	if first.Equals(second).False() {
+		t.Errorf("expected deterministic output under a fixed seed\nfirst:  %s\nsecond: %s", first.GoString(), second.GoString())
+	}
+}
+
+func TestComputedValuesForDataSource_jsonFormat(t *testing.T) {
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"policy": {
+				Type:     cty.String,
+				Computed: true,
+			},
+		},
+	}
+
+	target := cty.ObjectVal(map[string]cty.Value{
+		"policy": cty.NullVal(cty.String),
+	})
+
+	testRand = rand.New(rand.NewSource(0))
+	defer func() {
+		testRand = nil
+	}()
+
+	value, diags := ComputedValuesForDataSource(target, ReplacementValue{}, schema)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+
+	policy := value.GetAttr("policy").AsString()
+	var decoded map[string]interface{}
+	if err := json.Unmarshal([]byte(policy), &decoded); err != nil {
+		t.Errorf("generated policy %q is not valid JSON: %s", policy, err)
+	}
+
+	// And the output is deterministic under a fixed seed.
+	if policy != `{"mock":"ssnk9qhr"}` {
+		t.Errorf("unexpected policy under seed zero: %q", policy)
+	}
+}
+
+func TestNameSuggestsFormat(t *testing.T) {
+	tcs := []struct {
+		name     string
+		format   string
+		expected bool
+	}{
+		{"email", "email", true},
+		{"admin_email", "email", true},
+		{"EMAIL", "email", true},
+		{"emails", "email", false},
+		{"myemail", "email", false},
+		{"hostname", "hostname", true},
+		{"db_hostname", "hostname", true},
+	}
+
+	for _, tc := range tcs {
+		if actual := nameSuggestsFormat(tc.name, tc.format); actual != tc.expected {
+			t.Errorf("nameSuggestsFormat(%q, %q): expected %t, got %t", tc.name, tc.format, tc.expected, actual)
+		}
+	}
+}
+
+func TestComputedValuesForDataSource_descriptionHints(t *testing.T) {
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"identifier": {
+				Type:        cty.String,
+				Computed:    true,
+				Description: "The resource identifier. format: uuid",
+			},
+			"contact": {
+				Type:        cty.String,
+				Computed:    true,
+				Description: "Who to notify. Format: email",
+			},
+			"note": {
+				Type:        cty.String,
+				Computed:    true,
+				Description: "Free-form text with no hint at all.",
+			},
+		},
+	}
+
+	target := cty.ObjectVal(map[string]cty.Value{
+		"identifier": cty.NullVal(cty.String),
+		"contact":    cty.NullVal(cty.String),
+		"note":       cty.NullVal(cty.String),
+	})
+
+	testRand = rand.New(rand.NewSource(0))
+	defer func() {
+		testRand = nil
+	}()
+
+	generator := NewValueGenerator(GenerateOptions{
+		UseDescriptionHints: true,
+	})
+
+	value, diags := generator.ComputedValuesForDataSource(target, ReplacementValue{}, schema)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+
+	uuidRegexp := regexp.MustCompile(`^[a-z0-9]{8}-[a-z0-9]{4}-[a-z0-9]{4}-[a-z0-9]{4}-[a-z0-9]{12}$`)
+	if identifier := value.GetAttr("identifier").AsString(); !uuidRegexp.MatchString(identifier) {
+		t.Errorf("expected a uuid-shaped identifier, got %q", identifier)
+	}
+
+	contact := value.GetAttr("contact").AsString()
+	if _, err := mail.ParseAddress(contact); err != nil {
+		t.Errorf("generated contact %q is not a valid email address: %s", contact, err)
+	}
+
+	if note := value.GetAttr("note").AsString(); len(note) != 8 {
+		t.Errorf("expected a plain 8 character string for note, got %q", note)
+	}
+
+	// Hints should be completely ignored without the option.
+	testRand = rand.New(rand.NewSource(0))
+	value, _ = ComputedValuesForDataSource(target, ReplacementValue{}, schema)
+	if identifier := value.GetAttr("identifier").AsString(); uuidRegexp.MatchString(identifier) {
+		t.Errorf("did not expect a uuid-shaped identifier without the option, got %q", identifier)
+	}
+}
+
+func TestDescriptionHint(t *testing.T) {
+	tcs := []struct {
+		description string
+		expected    string
+	}{
+		{"format: uuid", "uuid"},
+		{"The identifier. format: uuid", "uuid"},
+		{"Format: EMAIL", "email"},
+		{"format:json", "json"},
+		{"no hint here", ""},
+		{"", ""},
+	}
+
+	for _, tc := range tcs {
+		if actual := descriptionHint(tc.description); actual != tc.expected {
+			t.Errorf("descriptionHint(%q): expected %q, got %q", tc.description, tc.expected, actual)
+		}
+	}
+}
+
+func TestComputedValuesForDataSource_cidrFormat(t *testing.T) {
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"cidr_block": {
+				Type:     cty.String,
+				Computed: true,
+			},
+		},
+	}
+
+	target := cty.ObjectVal(map[string]cty.Value{
+		"cidr_block": cty.NullVal(cty.String),
+	})
+
+	testRand = rand.New(rand.NewSource(0))
+	defer func() {
+		testRand = nil
+	}()
+
+	value, diags := ComputedValuesForDataSource(target, ReplacementValue{}, schema)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+
+	cidr := value.GetAttr("cidr_block").AsString()
+	if _, _, err := net.ParseCIDR(cidr); err != nil {
+		t.Errorf("generated cidr_block %q is not a valid CIDR: %s", cidr, err)
+	}
+
+	// And the output is deterministic under a fixed seed.
+	testRand = rand.New(rand.NewSource(0))
+	second, _ := ComputedValuesForDataSource(target, ReplacementValue{}, schema)
+	if again := second.GetAttr("cidr_block").AsString(); again != cidr {
+		t.Errorf("expected deterministic output under a fixed seed, got %q and %q", cidr, again)
+	}
+}
@@ -0,0 +1,2832 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package mocking
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+
+	"github.com/hashicorp/terraform/internal/addrs"
+	"github.com/hashicorp/terraform/internal/configs/configschema"
+	"github.com/hashicorp/terraform/internal/lang/marks"
+	"github.com/hashicorp/terraform/internal/tfdiags"
+)
+
+// GenerateOptions customises how the functions in this package generate
+// values for computed attributes.
+//
+// The zero value of GenerateOptions gives exactly the behaviour of the
+// package level functions, so callers only need to set the fields they care
+// about.
+type GenerateOptions struct {
+	// CollapseDiagnostics folds all the diagnostics raised during a single
+	// generation operation into one diagnostic whose detail lists each
+	// underlying problem as a bullet point.
+	//
+	// This is for callers that surface a single error per mocked resource
+	// rather than a set of them. By default every problem is returned as its
+	// own diagnostic.
+	CollapseDiagnostics bool
+
+	// FailFast stops an operation at the first error diagnostic instead of
+	// carrying on to collect every problem. The value returned alongside
+	// the error is the target unchanged, since generation was cut short.
+	// Large schemas with many broken overrides get much quicker feedback
+	// this way, at the cost of only hearing about one problem per run.
+	FailFast bool
+
+	// CaseInsensitiveOverrideKeys makes the lookup of replacement values
+	// tolerant of override keys that differ from the schema attribute names
+	// only by case or by surrounding whitespace.
+	//
+	// By default override keys must match the schema attribute names exactly.
+	CaseInsensitiveOverrideKeys bool
+
+	// MaxDepth is the maximum nesting depth at which computed values will be
+	// generated. Values nested more deeply than this produce an error
+	// diagnostic instead, as a guard against pathological or accidentally
+	// recursive schemas.
+	//
+	// A value of zero applies the default limit of ten levels. Callers with
+	// legitimately deep schemas can raise the limit per call.
+	MaxDepth int
+
+	// MaxSetElements caps how many elements of a single set the generator
+	// will process, with zero meaning no cap. Filling values inside sets is
+	// the most expensive part of generation because every changed element
+	// needs its identity recomputed, so callers mocking schemas with very
+	// large sets can cap the work here. Sets over the cap are left
+	// untouched and reported with an error diagnostic.
+	MaxSetElements int
+
+	// Shallow restricts generation to top-level computed attributes,
+	// leaving anything nested (inside blocks or nested-type attributes)
+	// exactly as it appears in the target. Skipped values that are unknown
+	// at apply time become null instead, since apply results may not
+	// contain unknowns. This is a cheap way to build a partial mock when
+	// only the top-level identifiers matter.
+	Shallow bool
+
+	// SensitivePredicates maps attribute paths (as rendered by fmtPath, e.g.
+	// "block.id") to predicates that decide, per operation, whether the value
+	// generated at that path should be marked as sensitive.
+	//
+	// Each predicate receives the whole target object, so it can make its
+	// decision based on sibling values, mirroring providers that declare
+	// attributes as conditionally sensitive.
+	SensitivePredicates map[string]func(cty.Value) bool
+
+	// Report, when non-nil, is filled in with metadata about each operation
+	// the generator performs. See GenerateReport for the available fields.
+	Report *GenerateReport
+
+	// Faker, when non-nil, is consulted for attributes whose names suggest a
+	// common kind of value (names, email addresses, and so on) before the
+	// built-in generators. This lets callers back value generation with a
+	// faker library of their choosing without this package depending on one.
+	Faker Faker
+
+	// Metrics, when non-nil, is filled in with profiling information about
+	// each operation the generator performs. See GenerateMetrics for the
+	// available measurements.
+	Metrics *GenerateMetrics
+
+	// RequireExplicitOverrides stops the generator inventing values: computed
+	// attributes with no corresponding replacement value are left null
+	// instead of being filled with semi-random data.
+	RequireExplicitOverrides bool
+
+	// OverridesMustBeComplete goes a step further than
+	// RequireExplicitOverrides: any computed attribute with no corresponding
+	// replacement value is an error, and the diagnostic lists every attribute
+	// that was missed. Use this for tests that want to be sure no mocked
+	// value was invented silently.
+	OverridesMustBeComplete bool
+
+	// StripAllMarks removes every mark (sensitive or otherwise) from the
+	// final value, after all mark-dependent logic has run. Some consumers of
+	// mocked values cannot handle marked values at all; this gives them a
+	// guaranteed mark-free result.
+	StripAllMarks bool
+
+	// ValidateOverrideDepth checks the shape of the replacement value against
+	// the schema before generation, reporting any part of the override that
+	// nests more deeply than the schema allows. Without this option such
+	// overrides only fail later, with a generic conversion error, or are
+	// silently ignored.
+	ValidateOverrideDepth bool
+
+	// ProtocolVersion hints at the plugin protocol version the generated
+	// value must be compatible with. Protocol versions before 6 have no
+	// representation for marks or unknown value refinements, so when an
+	// older version is requested the generator strips both from the final
+	// value. Zero means the current protocol, with no downgrading.
+	ProtocolVersion int
+
+	// Templates maps attribute paths (as rendered by fmtPath) to simple
+	// string templates used instead of a plain random string when generating
+	// a value for that path. Three placeholders are understood: ${type}
+	// expands to the ResourceType option, ${each.key} expands to the EachKey
+	// option, and ${random} expands to a single random string drawn from the
+	// usual generator (every occurrence in one template shares the same
+	// draw).
+	Templates map[string]string
+
+	// EachKey is the for_each key of the resource instance being mocked, for
+	// callers generating one value per instance of a map-expanded resource.
+	// It expands the ${each.key} placeholder in Templates, so instances can
+	// receive keyed identifiers like "mock-${each.key}" that stay traceable
+	// to the instance they belong to.
+	EachKey string
+
+	// ResourceType is the type of resource being mocked, e.g. "aws_instance".
+	// It is used to expand ${type} placeholders in Templates, and to name
+	// the resource in diagnostics when an InstanceKey is set.
+	ResourceType string
+
+	// ModulePath is the address of the module the mocked resource lives in,
+	// e.g. "module.network". When set, generated values for id-suggesting
+	// attributes carry the module path as a namespace prefix, so values
+	// mocked across several modules never collide and each one reads back
+	// to the module that produced it.
+	ModulePath string
+
+	// InstanceKey identifies which instance of an expanded resource is being
+	// mocked, for callers generating one value per instance of a resource
+	// with count or for_each. When set, every diagnostic raised by the
+	// operation names the indexed instance, so a failure among many mocked
+	// instances points at the right one. addrs.NoKey (and nil) leave
+	// diagnostics untouched.
+	InstanceKey addrs.InstanceKey
+
+	// ValidateGeneratedStrings adds a correctness guard over every generated
+	// string: a value that is not valid UTF-8, or that contains runes
+	// outside Charset when one is set, is reported with an error diagnostic.
+	// The built-in generators can't trip this, but caller-supplied Rand and
+	// Faker implementations can, and the guard turns their garbage into a
+	// clear report instead of a corrupted mock value.
+	ValidateGeneratedStrings bool
+
+	// Charset lists the runes generated strings are allowed to contain, for
+	// the ValidateGeneratedStrings guard. Leave it empty to check UTF-8
+	// validity only.
+	Charset string
+
+	// ReservedValues lists strings the generator must never produce, for
+	// callers whose systems treat certain strings (empty, "null", and so on)
+	// as sentinels. If a random draw collides with a reserved value the
+	// generator simply draws again, giving up after a bounded number of
+	// attempts to guarantee termination.
+	ReservedValues []string
+
+	// Rand supplies the source of randomness for every generated value.
+	// When nil, draws come from math/rand (or the seeded test source).
+	// Callers wanting crypto-backed or otherwise custom randomness plug
+	// their own source in here.
+	Rand RandSource
+
+	// Clock supplies the base time for generated timestamps. Attributes
+	// whose names suggest a timestamp (created_at, updated_at, and so on)
+	// are filled with the clock's current time in RFC 3339 form. When nil,
+	// the real system clock is used; tests wanting deterministic
+	// timestamps supply a fixed clock here.
+	Clock Clock
+
+	// StableIdentity, when non-empty, names the identity of the object being
+	// mocked (a resource address, say) and makes every generated string
+	// derive from that identity and the attribute's own path instead of the
+	// shared random stream. Values keyed this way don't depend on the order
+	// attributes happen to be visited in, so separate operations over the
+	// same identity - a plan and the apply that follows it, in particular -
+	// produce identical values.
+	StableIdentity string
+
+	// WarnOnConfigCollisions makes the generator emit a warning whenever a
+	// generated value happens to be identical to the value of a
+	// non-computed attribute in the target. A test assertion comparing the
+	// two would pass by coincidence, hiding an unintended coupling, so
+	// some callers prefer to hear about the collision.
+	WarnOnConfigCollisions bool
+
+	// CoerceJSONOverrides makes the generator try decoding an override
+	// supplied as a string into the attribute's actual type before giving
+	// up on a type mismatch. Users pasting JSON documents into overrides
+	// for list or object attributes is a common enough mistake to be worth
+	// tolerating, but the coercion is opt-in since a string that happens
+	// to parse as JSON might genuinely be meant as a string elsewhere.
+	CoerceJSONOverrides bool
+
+	// TakeFirstForSingleBlocks makes the generator accept an override that
+	// supplies a list of objects where the schema expects a single object,
+	// using the first element of the list as the override. Users who write a
+	// NestingSingle block override with the list syntax of a NestingList
+	// block are the usual source of these; by default such an override is an
+	// error, since a single block takes exactly one object.
+	TakeFirstForSingleBlocks bool
+
+	// StringLengths requests a specific length, in bytes, for the strings
+	// generated at individual attribute paths (as rendered by fmtPath)
+	// instead of the default of eight. Lengths over the hard cap of
+	// maxGeneratedStringLength are refused with an error diagnostic and
+	// fall back to the default, so a typo in a length can't balloon the
+	// memory used by a mocked value.
+	StringLengths map[string]int
+
+	// EmptyStringPaths lists computed string attribute paths (as rendered by
+	// fmtPath) that should be filled with an empty string instead of a
+	// random one. Some attributes just need to be present and non-null, and
+	// an empty string reads more clearly in test output than a random token
+	// would. Overrides still take precedence at these paths.
+	EmptyStringPaths []string
+
+	// Links declares that the attribute at each key path (as rendered by
+	// fmtPath) should end up equal to the value at the corresponding
+	// source path, resolved once generation has finished. This is pure
+	// equality linking for attribute pairs like id and secondary_id that
+	// providers keep in lockstep; anything more elaborate belongs in a
+	// transform.
+	Links map[string]string
+
+	// LinkOrder lists linked attribute paths (keys of Links, as rendered by
+	// fmtPath) in the order their links should resolve. Links that depend
+	// on each other need this: each ordered step sees the results of the
+	// steps before it, so a caller can topologically sort a chain of
+	// derived attributes. Links not named here resolve together afterwards,
+	// in no particular order.
+	LinkOrder []string
+
+	// DerivedBools declares that the computed bool attribute at each key
+	// path (as rendered by fmtPath) derives its value from a hash of the
+	// value at the corresponding source path, resolved once generation has
+	// finished. Equal source values always derive the same bool, so mocks
+	// that need a flag correlated with another attribute stay consistent
+	// across operations without the caller pinning the flag by hand.
+	DerivedBools map[string]string
+
+	// Echoes declares that the computed attribute at each key path (as
+	// rendered by fmtPath) copies the value the target holds at the
+	// corresponding input path. Data sources commonly echo a filter input
+	// into an output attribute this way (a matched_name output repeating
+	// the name input, say). Unlike Links, which resolve against the final
+	// generated value, echoes always read from the target as the caller
+	// supplied it.
+	Echoes map[string]string
+
+	// CanonicalizeMapKeys, when non-nil, rewrites every map key the
+	// generator invents (for MapBlockEntries and MinCollectionLengths map
+	// entries) before it is used. Providers that expect keys in a canonical
+	// form, lowercase say, can enforce that here. Keys the target already
+	// holds are never rewritten.
+	CanonicalizeMapKeys func(string) string
+
+	// MapBlockEntries requests, for NestingMap block paths (as rendered by
+	// fmtPath), that the generator invent this many entries when the block
+	// is empty in the target. Each entry gets a distinct generated key and
+	// a fully generated object. Blocks with existing entries are left
+	// alone. This only applies to map blocks; map-typed attributes are
+	// generated empty as usual.
+	MapBlockEntries map[string]int
+
+	// TupleComputedPositions lists, for tuple-typed attribute paths (as
+	// rendered by fmtPath), the element positions that behave as computed.
+	// The schema can't express per-position computed-ness for tuples, so
+	// callers that know which positions the provider fills in declare them
+	// here; those positions are generated when null and all others are
+	// preserved exactly as the target supplied them.
+	TupleComputedPositions map[string][]int
+
+	// ValuePools supplies pools of values for individual attribute paths
+	// (as rendered by fmtPath). Instead of generating a random value, the
+	// generator takes the next value from the pool in order, cycling back
+	// to the start when the pool runs out. Attributes broadcast across a
+	// collection draw one pool value per element, giving deterministic but
+	// varied values across the collection.
+	ValuePools map[string][]cty.Value
+
+	// UseDescriptionHints makes the generator look for a format hint like
+	// "format: uuid" in each attribute's schema description, and use the
+	// matching format generator when one is found. This is opt-in because
+	// descriptions are free-form documentation, and a provider might
+	// mention a format without meaning the whole value follows it.
+	UseDescriptionHints bool
+
+	// Base64Lengths maps string attribute paths (as rendered by fmtPath) to
+	// the number of random bytes to generate there, base64-encoded, instead
+	// of the usual random string. Attributes holding certificates, keys, and
+	// similar binary blobs can be mocked this way with values that survive
+	// whatever decodes them. The bytes come from the usual random source, so
+	// a fixed seed gives a fixed value.
+	Base64Lengths map[string]int
+
+	// JSONSchemas maps string attribute paths (as rendered by fmtPath) to a
+	// JSON Schema describing the documents the attribute holds. A matching
+	// attribute receives a minimal JSON document conforming to its schema:
+	// objects carry only their required properties, and enums pick one of
+	// the listed values at random. See JSONSchema for the supported subset.
+	JSONSchemas map[string]*JSONSchema
+
+	// NumberChoices maps number-typed attribute paths (as rendered by
+	// fmtPath) to the discrete values the attribute may take, for numbers
+	// constrained to a fixed set like well-known ports. The generator picks
+	// one of the choices at random instead of drawing from the default
+	// range, so a seeded source picks reproducibly.
+	NumberChoices map[string][]int64
+
+	// NumberRanges maps number-typed attribute paths (as rendered by
+	// fmtPath) to the inclusive bounds their values must fall within,
+	// mirroring provider-declared range validators. Generated numbers are
+	// drawn uniformly from the range instead of the default one, and an
+	// override outside the range is reported with an error diagnostic.
+	NumberRanges map[string]NumberRange
+
+	// MinCollectionLengths requests, for collection-typed attribute paths
+	// (as rendered by fmtPath), that a generated list, set, or map hold at
+	// least this many elements instead of the default of none. Elements are
+	// generated like any other value of the element type; map entries also
+	// get distinct random keys. A set whose element type can't produce
+	// enough distinct values to reach the minimum is an error.
+	MinCollectionLengths map[string]int
+
+	// NumberSetLength makes the generator fill computed sets of numbers
+	// with this many distinct random elements instead of leaving them
+	// empty. Distinctness matters because a set silently collapses
+	// duplicate elements, which would leave the set shorter than asked.
+	NumberSetLength int
+
+	// GloballyUniqueStrings makes the generator avoid producing the same
+	// string twice across every operation it performs, not just within a
+	// single value. Callers generating a batch of items with one generator
+	// use this to guarantee identifiers are unique across the whole batch.
+	// As with ReservedValues, the generator gives up after a bounded number
+	// of redraws, so uniqueness is best-effort for pathological seeds.
+	GloballyUniqueStrings bool
+
+	// Prior is the value this object held after a previous operation, for
+	// callers mocking the update path of a resource's lifecycle. Leave it as
+	// cty.NilVal when there is no prior state.
+	Prior cty.Value
+
+	// PriorSchema is the schema the target was produced under, for callers
+	// feeding in fixtures written against an older schema version. When set,
+	// the generator compares each attribute's computed flag between the two
+	// schemas and warns wherever they disagree: an attribute that is newly
+	// computed will have its stale configured value treated as settled, and
+	// one that is no longer computed keeps its generated value even though
+	// the configuration now owns it. The current schema always wins; the
+	// warnings just explain what that means for the affected attributes.
+	PriorSchema *configschema.Block
+
+	// ImmutableAttributes lists attribute paths (as rendered by fmtPath)
+	// whose values never change once created. When a Prior value is
+	// available, the generator reuses the prior value at these paths instead
+	// of generating a fresh one.
+	ImmutableAttributes []string
+
+	// IncrementingAttributes lists number attribute paths (as rendered by
+	// fmtPath) that behave as auto-incrementing counters, like a version
+	// number a provider bumps on every update. When a Prior value is
+	// available the generator produces the prior value plus one at these
+	// paths; without prior state they generate like any other number, which
+	// gives the counter its starting value.
+	IncrementingAttributes []string
+
+	// UnknownPolicy decides what happens to computed attributes that are
+	// still unknown when the apply step fills in values: generate a known
+	// value (the default), preserve the unknown, or report an error.
+	UnknownPolicy UnknownPolicy
+
+	// UnknownPolicyPerPath overrides UnknownPolicy for individual attribute
+	// paths (as rendered by fmtPath).
+	UnknownPolicyPerPath map[string]UnknownPolicy
+
+	// TypeTransforms are applied to every value of the matching type within
+	// the final object, in the order given. The first transform whose type
+	// matches a value wins, so more specific types should be listed before
+	// general ones.
+	TypeTransforms []TypeTransform
+
+	// AdvisoryAttributes lists attribute paths (as rendered by fmtPath)
+	// that the caller knows cannot be mocked meaningfully: opaque tokens,
+	// signed URLs, and the like, where no random placeholder will satisfy
+	// whatever consumes the value. Inventing a value at one of these paths
+	// raises an advisory warning suggesting an override; supplying an
+	// override raises nothing.
+	AdvisoryAttributes []string
+
+	// AnonymizeAttributes lists attribute paths (as rendered by fmtPath)
+	// whose final string values should be replaced with deterministic
+	// pseudonyms once generation has finished. Each pseudonym is derived
+	// from a hash of the value it replaces, so equal inputs always map to
+	// the same pseudonym and distinct inputs stay distinguishable, but the
+	// original values never appear in the output. This makes mocked values
+	// built from real data safe to share.
+	AnonymizeAttributes []string
+
+	// EphemeralAttributes lists attribute paths (as rendered by fmtPath)
+	// whose values are ephemeral: they exist only during apply and are never
+	// persisted. Generated values at these paths are marked with
+	// marks.Ephemeral during PhaseApply; during PhasePlan they are kept
+	// unknown with no mark, since an unresolved value has nothing to
+	// protect yet. Without a phase the mark is applied unconditionally.
+	EphemeralAttributes []string
+
+	// Phase tells the generator which phase of an operation it is mocking,
+	// which changes how optional computed attributes are treated. During
+	// plan an optional computed attribute the configuration left null is
+	// not resolved until apply, so PhasePlan keeps such attributes unknown
+	// instead of generating values for them. PhaseApply, and the zero
+	// value, generate values for them like any other computed attribute.
+	Phase Phase
+
+	// TargetType, when not cty.NilType, makes the generator convert the
+	// final object to this type before returning it, using the standard
+	// conversion rules. Callers that need the result to conform to a wider
+	// type than the schema implies (an object with extra optional
+	// attributes, say) can ask for that here rather than converting
+	// afterwards themselves. Conversion failures are reported as error
+	// diagnostics and leave the value unconverted.
+	TargetType cty.Type
+
+	// ExactlyOneOf lists groups of attribute paths (as rendered by fmtPath)
+	// of which exactly one may hold a value, mirroring the provider SDK
+	// constraint of the same name. Within each group the generator fills
+	// only one member: whichever one the target already holds a value for,
+	// or the first listed member when none is set. The rest stay null.
+	// Overrides are the caller's explicit choice and are applied regardless.
+	ExactlyOneOf [][]string
+
+	// ConflictsWith lists groups of attribute paths (as rendered by fmtPath)
+	// of which at most one may hold a value. The generator treats these
+	// exactly like ExactlyOneOf groups: it never invents values for more
+	// than one member of a group.
+	ConflictsWith [][]string
+
+	// RequiredWith lists groups of attribute paths (as rendered by fmtPath)
+	// that the provider declares as required together: an override that sets
+	// any member of a group should set them all. Overriding only part of a
+	// group raises a warning naming the members that were missed. This is
+	// advisory only; generation proceeds with whatever was supplied.
+	RequiredWith [][]string
+
+	// Profile names a built-in bundle of format generators that make
+	// generated identifiers look like a particular cloud's, without setting
+	// up a Faker or Templates by hand. See ProfileAWSLike and ProfileGCPLike
+	// for what each bundle covers; attribute names a profile doesn't
+	// recognise fall back to the usual generators. Naming a profile that
+	// doesn't exist is an error.
+	Profile string
+
+	// AlsoConformsTo, when non-nil, is a second schema the final value must
+	// also conform to, checked by attempting a conversion to that schema's
+	// implied type. The value itself is never changed; an incompatibility
+	// just produces an error diagnostic. Callers testing a schema migration
+	// use this to confirm values mocked under the old schema still fit the
+	// new one.
+	AlsoConformsTo *configschema.Block
+
+	// DiagnosticFormatter, when non-nil, rewrites the summary and detail
+	// text of every diagnostic an operation raises, receiving the original
+	// texts and returning the replacements. The underlying checks, the
+	// severities, and the attribute paths are unchanged; this only exists
+	// so callers can localise or rephrase the messages.
+	DiagnosticFormatter func(summary, detail string) (string, string)
+
+	// DiagnosticsTransform, when non-nil, post-processes the complete set of
+	// diagnostics an operation raises, receiving the slice after every other
+	// diagnostic option has run and returning the slice to hand to the
+	// caller. Unlike DiagnosticFormatter this sees the whole set at once, so
+	// it can deduplicate, reorder, or drop diagnostics as well as rewrite
+	// them. CollapseDiagnostics still runs afterwards, on whatever the
+	// transform returns.
+	DiagnosticsTransform func(tfdiags.Diagnostics) tfdiags.Diagnostics
+
+	// ReconcileTypeSkew handles targets written against an older version of
+	// the schema, where a computed attribute's type has since changed. A
+	// known value whose type no longer matches the schema is converted to
+	// the schema type when possible; when it isn't, a fresh value is
+	// generated at the schema type and a warning explains what happened.
+	// Without the option such values are preserved as they are, types and
+	// all.
+	ReconcileTypeSkew bool
+
+	// OverrideFilter, when non-nil, is invoked for every override value the
+	// replacement lookup resolves, before it is applied. The hook can
+	// rewrite the value by returning a different one with true, or veto it
+	// entirely by returning false, in which case the attribute falls through
+	// to generation as if no override had been supplied.
+	OverrideFilter func(path cty.Path, supplied cty.Value) (cty.Value, bool)
+
+	// OverridesReplaceKnown makes an override win even when the target
+	// already holds a known value for the computed attribute it covers. By
+	// default the known value is treated as settled and the override is
+	// ignored; with this option the override takes precedence instead.
+	OverridesReplaceKnown bool
+
+	// WarnOnReplacedKnown emits a warning each time OverridesReplaceKnown
+	// actually discards a known value, for callers that want the precedence
+	// but still want to hear when it kicks in.
+	WarnOnReplacedKnown bool
+
+	// Validators maps attribute paths (as rendered by fmtPath) to predicates
+	// that a generated value must satisfy. When the generator invents a
+	// value the predicate rejects, it simply draws again, giving up with an
+	// error diagnostic after a bounded number of attempts. Validators only
+	// apply to invented values; overrides are the caller's explicit choice
+	// and bypass them.
+	Validators map[string]func(cty.Value) bool
+
+	// ShuffleLists lists paths (as rendered by fmtPath) to list values
+	// within the final object whose elements should be reordered with a
+	// random shuffle once generation has finished. Tests that shouldn't
+	// depend on list order can use this to vary the order while staying
+	// reproducible: the shuffle draws from the same random source as
+	// everything else, so a fixed seed gives a fixed order.
+	ShuffleLists []string
+
+	// DistinctAttributes lists attribute paths (as rendered by fmtPath)
+	// whose final values must all differ from one another. Identifiers that
+	// a provider guarantees to be mutually distinct can be declared here,
+	// and any collision between two of the listed paths - whether the values
+	// were generated or overridden - is reported with an error diagnostic.
+	DistinctAttributes []string
+
+	// RequireUniqueListElements lists paths (as rendered by fmtPath) to list
+	// values within the final object that must not contain two identical
+	// elements. Lists are checked after generation, and any duplicates
+	// produce an error diagnostic. This catches overrides that broadcast
+	// the same value across a collection that the caller expects to be
+	// distinct.
+	RequireUniqueListElements []string
+}
+
+// Phase is the set of operation phases a generator can mock. See the Phase
+// option.
+type Phase int
+
+const (
+	// PhaseUnspecified applies no phase-specific behaviour. This is the
+	// default.
+	PhaseUnspecified Phase = iota
+
+	// PhasePlan mocks the plan phase, keeping optional computed attributes
+	// unknown since their final values aren't decided until apply.
+	PhasePlan
+
+	// PhaseApply mocks the apply phase, generating values for every
+	// computed attribute.
+	PhaseApply
+)
+
+// UnknownPolicy is the set of choices for what the generator does with
+// unknown computed values during apply. See the UnknownPolicy option.
+type UnknownPolicy int
+
+const (
+	// UnknownPolicyGenerate replaces unknown computed values with generated
+	// known values. This is the default.
+	UnknownPolicyGenerate UnknownPolicy = iota
+
+	// UnknownPolicyPreserve leaves unknown computed values untouched.
+	UnknownPolicyPreserve
+
+	// UnknownPolicyError reports an error diagnostic for any computed value
+	// that is still unknown, leaving the value untouched.
+	UnknownPolicyError
+)
+
+// NumberRange is an inclusive range of integer values a number attribute may
+// take. See the NumberRanges option.
+type NumberRange struct {
+	Min, Max int64
+}
+
+// Clock provides the current time to the generator. See the Clock option.
+type Clock interface {
+	Now() time.Time
+}
+
+// systemClock implements Clock with the real system time, and is what the
+// generator uses when the caller doesn't supply a clock.
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// Faker produces realistic values for common kinds of attributes. Callers
+// can implement it with any data faking library, or by hand, and supply it
+// via GenerateOptions to have the generator prefer its output over the
+// built-in random values.
+//
+// Implementations that want reproducible output should derive their values
+// from a seeded random source.
+type Faker interface {
+	// Name returns a person-like name.
+	Name() string
+
+	// Email returns a well-formed email address.
+	Email() string
+
+	// Hostname returns a well-formed DNS hostname.
+	Hostname() string
+
+	// UUID returns a UUID-shaped string.
+	UUID() string
+}
+
+// fakeForName maps an attribute name to the appropriate Faker method, if
+// there is one.
+func fakeForName(faker Faker, name string) (string, bool) {
+	switch {
+	case nameSuggestsFormat(name, "name"):
+		return faker.Name(), true
+	case nameSuggestsFormat(name, "email"):
+		return faker.Email(), true
+	case nameSuggestsFormat(name, "hostname"), nameSuggestsFormat(name, "host"):
+		return faker.Hostname(), true
+	case nameSuggestsFormat(name, "uuid"):
+		return faker.UUID(), true
+	default:
+		return "", false
+	}
+}
+
+// ValueGenerator generates values for computed attributes, as the package
+// level functions PlanComputedValuesForResource,
+// ApplyComputedValuesForResource and ComputedValuesForDataSource do, but with
+// the behaviour customised by a set of GenerateOptions.
+//
+// A ValueGenerator is not safe for concurrent use.
+type ValueGenerator struct {
+	opts GenerateOptions
+
+	// missingOverrides accumulates, during a single operation, the paths of
+	// computed attributes that had no replacement value while
+	// OverridesMustBeComplete was set.
+	missingOverrides []string
+
+	// schema is the schema of the operation currently in progress, kept so
+	// the generation helpers can look attributes up by path without having
+	// to thread the schema through every call.
+	schema *configschema.Block
+
+	// poolIndices tracks, during a single operation, how many values have
+	// been taken from each of the caller's value pools, so consecutive
+	// draws cycle through each pool in order.
+	poolIndices map[string]int
+
+	// cappedSets tracks, during a single operation, the sets that exceeded
+	// MaxSetElements so each is only reported once no matter how many
+	// elements it has.
+	cappedSets map[string]bool
+
+	// generatedStrings remembers every string the generator has produced so
+	// far while GloballyUniqueStrings is set. Unlike missingOverrides, this
+	// deliberately survives across operations so a batch of values generated
+	// by a single generator shares one dedup set.
+	generatedStrings map[string]bool
+}
+
+// NewValueGenerator returns a ValueGenerator that will apply the given
+// options to every operation it performs.
+func NewValueGenerator(opts GenerateOptions) *ValueGenerator {
+	return &ValueGenerator{
+		opts: opts,
+	}
+}
+
+// PlanComputedValuesForResource behaves as the package level function of the
+// same name, honouring the generator's options.
+func (g *ValueGenerator) PlanComputedValuesForResource(original cty.Value, schema *configschema.Block) (cty.Value, tfdiags.Diagnostics) {
+	return g.populateComputedValues(original, ReplacementValue{}, schema, isNull, makeUnknown)
+}
+
+// ApplyComputedValuesForResource behaves as the package level function of the
+// same name, honouring the generator's options.
+func (g *ValueGenerator) ApplyComputedValuesForResource(original cty.Value, with ReplacementValue, schema *configschema.Block) (cty.Value, tfdiags.Diagnostics) {
+	return g.populateComputedValues(original, with, schema, isUnknown, g.makeKnown(with))
+}
+
+// ComputedValuesForDataSource behaves as the package level function of the
+// same name, honouring the generator's options.
+func (g *ValueGenerator) ComputedValuesForDataSource(original cty.Value, with ReplacementValue, schema *configschema.Block) (cty.Value, tfdiags.Diagnostics) {
+	return g.populateComputedValues(original, with, schema, isNull, g.makeKnown(with))
+}
+
+// populateComputedValues implements every operation of this package: walk the
+// target, replace each value that is both computed and matched by processValue
+// with the output of populateValue, and then apply whichever post-processing
+// steps the options ask for.
+//
+// The returned value is best-effort: a problem with one attribute is reported
+// as a diagnostic while every other attribute is still processed, so even when
+// the diagnostics contain errors the value is valid and holds everything that
+// did generate successfully. Callers can inspect the partial result to see how
+// far generation got. FailFast is the one exception, trading the partial
+// result away for an early stop.
+func (g *ValueGenerator) populateComputedValues(target cty.Value, with ReplacementValue, schema *configschema.Block, processValue processValue, populateValue populateValue) (cty.Value, tfdiags.Diagnostics) {
+	var diags tfdiags.Diagnostics
+
+	if g.opts.Report != nil {
+		g.opts.Report.ImpliedType = schema.ImpliedType()
+		g.opts.Report.AbsentAttributes = absentAttributes(target, schema)
+
+		effective := g.opts
+		effective.MaxDepth = g.maxDepth()
+		effective.Rand = g.rand()
+		effective.Clock = g.clock()
+		effective.Report = nil
+		g.opts.Report.EffectiveOptions = &effective
+	}
+	g.missingOverrides = nil
+	g.cappedSets = nil
+	g.poolIndices = nil
+	g.schema = schema
+
+	if g.opts.Profile != "" && !knownProfile(g.opts.Profile) {
+		diags = diags.Append(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Unknown generation profile",
+			fmt.Sprintf("There is no built-in generation profile named %q. The available profiles are %q and %q.", g.opts.Profile, ProfileAWSLike, ProfileGCPLike)))
+	}
+
+	if !with.validate() {
+		// This is actually a user error, it means the user wrote something like
+		// `values = "not an object"` when defining the replacement values for
+		// this in the mock or test file. We should have caught this earlier in
+		// the validation, but we want this function to be robust and not panic
+		// so we'll check again just in case.
+		diags = diags.Append(&hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Invalid replacement value",
+			Detail:   fmt.Sprintf("The requested replacement value must be an object type, but was %s.", with.Value.Type().FriendlyName()),
+			Subject:  with.Range.Ptr(),
+		})
+	}
+
+	// We're going to search for any elements within the target value that meet
+	// the joint criteria of being computed and whatever processValue is
+	// checking.
+	//
+	// We'll then replace anything that meets the criteria with the output of
+	// populateValue.
+	//
+	if g.opts.ValidateOverrideDepth {
+		diags = diags.Append(withCauseAll(validateOverrideDepth(with, schema), CauseOverride))
+	}
+
+	if len(g.opts.RequiredWith) > 0 {
+		diags = diags.Append(g.requiredWithWarnings(with))
+	}
+
+	if g.opts.CaseInsensitiveOverrideKeys {
+		diags = diags.Append(ambiguousOverrideKeys(with))
+	}
+
+	if g.opts.PriorSchema != nil {
+		diags = diags.Append(computedFlagWarnings(g.opts.PriorSchema, schema, nil))
+	}
+
+	// Nested-type attributes with group nesting behave like NestingGroup
+	// blocks: they are always present from the perspective of the
+	// configuration, even if the target holds a null. Materialise any absent
+	// groups first so the search below can fill their computed fields like
+	// any others.
+	target = expandGroupAttributes(target, schema)
+
+	// This transform should be robust (in that it should never fail), it'll
+	// populate the external diags variable with any values it should have
+	// replaced but couldn't and just return the original value.
+	root := target
+	value, err := cty.Transform(target, func(path cty.Path, target cty.Value) (cty.Value, error) {
+
+		if g.opts.FailFast && diags.HasErrors() {
+			// An earlier value already failed, and the caller asked to stop
+			// at the first problem rather than keep collecting.
+			return target, errFailFast
+		}
+
+		if g.opts.Metrics != nil {
+			defer g.opts.Metrics.record(path, time.Now())
+		}
+
+		if g.opts.MaxSetElements > 0 && len(path) > 0 {
+			if setPath, exceeded := g.oversizedSetPrefix(root, path); exceeded {
+				rendered := fmtPath(setPath)
+				if !g.cappedSets[rendered] {
+					if g.cappedSets == nil {
+						g.cappedSets = make(map[string]bool)
+					}
+					g.cappedSets[rendered] = true
+					diags = diags.Append(tfdiags.AttributeValue(
+						tfdiags.Error,
+						"Set too large to mock",
+						fmt.Sprintf("Terraform did not generate values inside %s because it has more than %d elements. Raise the MaxSetElements generation option if the set is legitimately this large.", rendered, g.opts.MaxSetElements),
+						setPath))
+				}
+				return target, nil
+			}
+		}
+
+		// Get the attribute for the current target.
+		attribute := schema.AttributeByPath(path)
+
+		if attribute == nil {
+			// Then this is an intermediate path which does not represent an
+			// attribute directly. It may still be an element of a computed
+			// collection-typed attribute, though: a computed list(map(string))
+			// or map(list(string)) holds its maps and lists as elements
+			// without schema entries of their own. Null elements of such
+			// attributes get filled like any other computed value, while
+			// known elements keep whatever they arrived with.
+			if element := attributeForElement(schema, path); element != nil && element.Computed && (target.Type().IsMapType() || target.Type().IsListType()) && processValue(target) {
+				value, valueDiags := populateValue(target, cty.NilVal, path)
+				diags = diags.Append(valueDiags)
+				return value, nil
+			}
+
+			// Empty map blocks can have whole entries invented for them on
+			// request, complete with generated keys.
+			if count, ok := g.opts.MapBlockEntries[fmtPath(path)]; ok && count > 0 {
+				if block := blockByPath(schema, path); block != nil && block.Nesting == configschema.NestingMap {
+					if target.IsKnown() && !target.IsNull() && target.Type().IsMapType() && target.LengthInt() == 0 {
+						value, valueDiags := g.generateMapBlockEntries(block, count, path, populateValue)
+						diags = diags.Append(valueDiags)
+						return value, nil
+					}
+				}
+			}
+
+			// Values nested inside a plain object-typed computed attribute
+			// also get filled: a provider may have planned such an attribute
+			// as a known object with refined unknowns inside, and those
+			// inner unknowns are still ours to resolve at apply time.
+			if attribute := innerComputedAttribute(schema, path); attribute != nil && processValue(target) {
+				value, valueDiags := populateValue(target, cty.NilVal, path)
+				diags = diags.Append(valueDiags)
+				return value, nil
+			}
+
+			// Tuple elements are similar, except per-position computed-ness
+			// comes from the caller's hints rather than the schema.
+			if positions, ok := g.opts.TupleComputedPositions[fmtPath(path)]; ok && processValue(target) {
+				if index, isElement := elementIndex(path); isElement {
+					for _, position := range positions {
+						if position == index {
+							value, valueDiags := populateValue(target, cty.NilVal, path)
+							diags = diags.Append(valueDiags)
+							return value, nil
+						}
+					}
+				}
+			}
+			return target, nil
+		}
+
+		// Now, we check if we should be replacing this value with something.
+		// Computed attributes are always our job to fill. Required fields
+		// inside an optional nested-type attribute are too: when the
+		// configuration includes the object at all its required fields must
+		// not be null, and when the object is absent we never get here
+		// because there is nothing to descend into.
+		// A known value whose type no longer matches the schema came from a
+		// fixture written against an older schema version. With the option
+		// set we reconcile it to the schema type rather than preserving it.
+		if g.opts.ReconcileTypeSkew && attribute.Computed && attribute.NestedType == nil &&
+			target.IsKnown() && !target.IsNull() && !target.Type().Equals(attribute.Type) {
+			if converted, err := convert.Convert(target, attribute.Type); err == nil {
+				return converted, nil
+			}
+
+			diags = diags.Append(tfdiags.AttributeValue(
+				tfdiags.Warning,
+				"Mocked value regenerated after type change",
+				fmt.Sprintf("The target value for %s is %s, but the schema now gives the attribute type %s and the value could not be converted, so a fresh value was generated instead.", fmtPath(path), target.Type().FriendlyName(), attribute.Type.FriendlyName()),
+				path))
+
+			value, valueDiags := populateValue(cty.UnknownVal(attribute.Type), cty.NilVal, path)
+			diags = diags.Append(valueDiags)
+			return value, nil
+		}
+
+		// With OverridesReplaceKnown, an override also displaces a computed
+		// value the target already holds, rather than deferring to it.
+		replaceKnown := false
+		if g.opts.OverridesReplaceKnown && attribute.Computed && !processValue(target) {
+			if probe, _ := with.getReplacementSafe(path, g.opts); probe != cty.NilVal {
+				replaceKnown = true
+			}
+		}
+
+		if ((attribute.Computed || g.requiredInsideOptionalObject(path, attribute)) && processValue(target)) || replaceKnown {
+
+			if g.opts.Phase == PhasePlan && attribute.Optional {
+				// Optional computed attributes aren't resolved until apply,
+				// so during plan they stay unknown rather than receiving a
+				// generated value.
+				return cty.UnknownVal(target.Type()), nil
+			}
+
+			if g.opts.Shallow && len(path) > 1 {
+				// In shallow mode only top-level computed attributes are
+				// filled. A skipped nested value that is unknown at apply
+				// time becomes null, since apply results may not contain
+				// unknowns; otherwise it stays exactly as the target
+				// provided it.
+				if !target.IsKnown() {
+					return cty.NullVal(target.Type()), nil
+				}
+				return target, nil
+			}
+
+			if len(path) > g.maxDepth() {
+				// We've recursed deeper than the caller allowed, so we leave
+				// the value untouched and report why.
+				diags = diags.Append(tfdiags.AttributeValue(
+					tfdiags.Error,
+					"Failed to generate value",
+					fmt.Sprintf("Terraform could not generate a value for %s because it is nested more than %d levels deep. Raise the MaxDepth generation option if the schema is legitimately this deep.", fmtPath(path), g.maxDepth()),
+					path))
+				return target, nil
+			}
+
+			// Get the value we should be replacing target with.
+			replacement, replacementDiags := with.getReplacementSafe(path, g.opts)
+			diags = diags.Append(withCauseAll(replacementDiags, CauseOverride))
+
+			if g.opts.OverrideFilter != nil && replacement != cty.NilVal {
+				if rewritten, ok := g.opts.OverrideFilter(path, replacement); ok {
+					replacement = rewritten
+				} else {
+					// The hook vetoed the override, so the attribute falls
+					// through to generation.
+					replacement = cty.NilVal
+				}
+			}
+
+			if g.opts.Report != nil && replacement != cty.NilVal {
+				if g.opts.Report.ResolvedOverrides == nil {
+					g.opts.Report.ResolvedOverrides = make(map[string]cty.Value)
+				}
+				g.opts.Report.ResolvedOverrides[fmtPath(path)] = replacement
+				g.opts.Report.SuppliedCount++
+			}
+
+			if replaceKnown && g.opts.WarnOnReplacedKnown && replacement != cty.NilVal {
+				diags = diags.Append(withCause(tfdiags.AttributeValue(
+					tfdiags.Warning,
+					"Override replaces known value",
+					fmt.Sprintf("The target already held a known value for %s, but the replacement value defined within %s takes precedence over it.", fmtPath(path), with.Range),
+					path), CauseOverride))
+			}
+
+			if replacement == cty.NilVal && g.constraintSkips(root, path) {
+				// Another member of this attribute's constraint group is the
+				// one that receives a value. During apply the target here is
+				// unknown and must not leak into the result, so it becomes
+				// null; otherwise it stays as it is.
+				if !target.IsKnown() {
+					return cty.NullVal(target.Type()), nil
+				}
+				return target, nil
+			}
+
+			// Upstream code (in node_resource_abstract_instance.go) expects
+			// us to return a valid object (even if we have errors). That means
+			// no unknown values, no cty.NilVals, etc. So, we're going to go
+			// ahead and call populateValue with whatever getReplacementSafe
+			// gave us. getReplacementSafe is robust, so even in an error it
+			// should have given us something we can use in populateValue.
+
+			// Now get the replacement value. This function should be robust in
+			// that it may return diagnostics explaining why it couldn't replace
+			// the value, but it'll still return a value for us to use.
+			value, valueDiags := populateValue(target, replacement, path)
+			diags = diags.Append(valueDiags)
+
+			if validator, ok := g.opts.Validators[fmtPath(path)]; ok && replacement == cty.NilVal {
+				attempts := 1
+				for ; !validator(value) && attempts < maxValidationAttempts; attempts++ {
+					var retryDiags tfdiags.Diagnostics
+					value, retryDiags = populateValue(target, replacement, path)
+					diags = diags.Append(retryDiags)
+				}
+				if !validator(value) {
+					diags = diags.Append(tfdiags.AttributeValue(
+						tfdiags.Error,
+						"Failed to generate valid value",
+						fmt.Sprintf("Terraform could not generate a value for %s that satisfies the caller-supplied validator within %d attempts.", fmtPath(path), attempts),
+						path))
+				}
+			}
+
+			if g.opts.Report != nil && replacement == cty.NilVal {
+				if g.opts.Report.AlgorithmVersions == nil {
+					g.opts.Report.AlgorithmVersions = make(map[string]int)
+				}
+				g.opts.Report.AlgorithmVersions[fmtPath(path)] = algorithmVersion
+				g.opts.Report.GeneratedCount++
+			}
+
+			if replacement == cty.NilVal && g.isAdvisory(path) {
+				diags = diags.Append(tfdiags.AttributeValue(
+					tfdiags.Warning,
+					"Mocked value may not satisfy provider validation",
+					fmt.Sprintf("The value for %s is a generated placeholder, and the caller flagged this attribute as one a placeholder is unlikely to satisfy. Supply an override if something consumes this value.", fmtPath(path)),
+					path))
+			}
+
+			if g.opts.WarnOnConfigCollisions && replacement == cty.NilVal {
+				diags = diags.Append(configCollisionWarnings(root, schema, path, value))
+			}
+
+			if predicate, ok := g.opts.SensitivePredicates[fmtPath(path)]; ok && predicate(root) {
+				// The caller declared this attribute as conditionally
+				// sensitive, and the predicate says the condition holds.
+				value = value.Mark(marks.Sensitive)
+				g.recordMark(path, marks.Sensitive)
+			}
+
+			if g.isEphemeral(path) {
+				if g.opts.Phase == PhasePlan {
+					// Ephemeral values aren't resolved until apply, and the
+					// mark is withheld while the value is unknown.
+					return cty.UnknownVal(target.Type()), nil
+				}
+				value = value.Mark(marks.Ephemeral)
+				g.recordMark(path, marks.Ephemeral)
+			}
+
+			// We always return a valid value, the diags are attached to the
+			// global diags outside the nested function.
+			return value, nil
+		}
+
+		// If we don't need to replace this value, then just return it
+		// untouched. For a computed attribute that means the target already
+		// held a usable value, which the report counts as preserved.
+		if g.opts.Report != nil && attribute.Computed {
+			g.opts.Report.PreservedCount++
+		}
+		return target, nil
+	})
+	if err == errFailFast {
+		// The walk was cut short deliberately. The target goes back
+		// unchanged: upstream code still needs a valid object, and a
+		// half-generated one would be misleading.
+		return target, diags
+	}
+	if err != nil {
+		// This shouldn't actually happen - we never return an error from inside
+		// the transform function. But, just in case:
+		diags = diags.Append(&hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Detail:   "Failed to generate values",
+			Summary:  fmt.Sprintf("Terraform failed to generate computed values for a mocked resource, data source, or module: %s. This is a bug in Terraform - please report it.", err),
+			Subject:  with.Range.Ptr(),
+		})
+
+		// A failed transform yields cty.NilVal, and our contract is to always
+		// hand back a usable value, so fall back to the target.
+		value = target
+	}
+
+	if len(g.opts.Echoes) > 0 {
+		var echoDiags tfdiags.Diagnostics
+		value, echoDiags = g.resolveEchoes(value, root)
+		diags = diags.Append(echoDiags)
+	}
+
+	if len(g.opts.Links) > 0 {
+		var linkDiags tfdiags.Diagnostics
+		value, linkDiags = g.resolveLinks(value)
+		diags = diags.Append(linkDiags)
+	}
+
+	if len(g.opts.AnonymizeAttributes) > 0 {
+		value = g.anonymizeAttributes(value)
+	}
+
+	if len(g.opts.DerivedBools) > 0 {
+		var boolDiags tfdiags.Diagnostics
+		value, boolDiags = g.resolveDerivedBools(value)
+		diags = diags.Append(boolDiags)
+	}
+
+	if len(g.opts.TypeTransforms) > 0 {
+		value = g.applyTypeTransforms(value)
+	}
+
+	if len(g.opts.ShuffleLists) > 0 {
+		value = g.shuffleLists(value)
+	}
+
+	for _, listPath := range g.opts.RequireUniqueListElements {
+		diags = diags.Append(checkUniqueListElements(value, listPath))
+	}
+
+	if len(g.opts.DistinctAttributes) > 0 {
+		diags = diags.Append(g.checkDistinctAttributes(value))
+	}
+
+	if g.opts.ProtocolVersion > 0 && g.opts.ProtocolVersion < 6 {
+		value = downgradeForProtocol(value)
+	}
+
+	if g.opts.TargetType != cty.NilType {
+		if converted, err := convert.Convert(value, g.opts.TargetType); err != nil {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"Failed to convert generated value",
+				fmt.Sprintf("Terraform could not convert the generated value to the requested target type: %s.", err)))
+		} else {
+			value = converted
+		}
+	}
+
+	if g.opts.AlsoConformsTo != nil {
+		if problems := conformanceProblems(value, g.opts.AlsoConformsTo); len(problems) > 0 {
+			diags = diags.Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"Generated value does not conform to second schema",
+				fmt.Sprintf("The generated value is not compatible with the second schema the generation options require it to conform to: %s.", strings.Join(problems, "; "))))
+		}
+	}
+
+	if g.opts.Report != nil {
+		g.opts.Report.SetOrderings = setOrderings(value)
+	}
+
+	if g.opts.StripAllMarks {
+		// This must stay the last step that touches the value, so that any
+		// logic which depends on the marks has already seen them.
+		value, _ = value.UnmarkDeep()
+	}
+
+	if len(g.missingOverrides) > 0 {
+		sort.Strings(g.missingOverrides)
+		diags = diags.Append(withCause(tfdiags.Sourceless(
+			tfdiags.Error,
+			"Incomplete mock overrides",
+			fmt.Sprintf("The generation options require overrides for all computed attributes, but the following attributes have none: %s.", strings.Join(g.missingOverrides, ", "))), CauseOverride))
+	}
+
+	if g.opts.InstanceKey != nil && g.opts.InstanceKey != addrs.NoKey {
+		diags = annotateInstanceKey(diags, g.instanceAddr())
+	}
+
+	if g.opts.DiagnosticFormatter != nil {
+		diags = rebuildDiagnostics(diags, g.opts.DiagnosticFormatter)
+	}
+
+	if g.opts.DiagnosticsTransform != nil {
+		diags = g.opts.DiagnosticsTransform(diags)
+	}
+
+	if g.opts.CollapseDiagnostics {
+		diags = collapseDiagnostics(diags)
+	}
+
+	return value, diags
+}
+
+// instanceAddr renders the address of the instance being mocked, combining
+// the ResourceType option (when set) with the instance key.
+func (g *ValueGenerator) instanceAddr() string {
+	if g.opts.ResourceType != "" {
+		return fmt.Sprintf("%s%s", g.opts.ResourceType, g.opts.InstanceKey)
+	}
+	return fmt.Sprintf("instance %s", g.opts.InstanceKey)
+}
+
+// annotateInstanceKey rebuilds each diagnostic with a sentence naming the
+// instance the operation was mocking. Attribute paths and causes carry over;
+// only the detail text changes.
+func annotateInstanceKey(diags tfdiags.Diagnostics, addr string) tfdiags.Diagnostics {
+	return rebuildDiagnostics(diags, func(summary, detail string) (string, string) {
+		return summary, fmt.Sprintf("%s This happened while mocking %s.", detail, addr)
+	})
+}
+
+// rebuildDiagnostics rebuilds each diagnostic with its summary and detail
+// rewritten by the given function, keeping the severity, attribute path, and
+// cause of the original.
+func rebuildDiagnostics(diags tfdiags.Diagnostics, rewrite func(summary, detail string) (string, string)) tfdiags.Diagnostics {
+	if len(diags) == 0 {
+		return diags
+	}
+
+	rebuilt := make(tfdiags.Diagnostics, 0, len(diags))
+	for _, diag := range diags {
+		desc := diag.Description()
+		summary, detail := rewrite(desc.Summary, desc.Detail)
+
+		// A diagnostic carrying a cause is wrapped, so unwrap it before
+		// asking for the attribute path.
+		var replacement tfdiags.Diagnostic
+		if path := tfdiags.GetAttribute(tfdiags.UndoOverride(diag)); path != nil {
+			replacement = tfdiags.AttributeValue(diag.Severity(), summary, detail, path)
+		} else {
+			replacement = tfdiags.Sourceless(diag.Severity(), summary, detail)
+		}
+		rebuilt = rebuilt.Append(withCause(replacement, DiagnosticCauseOf(diag)))
+	}
+	return rebuilt
+}
+
+// resolveEchoes rewrites every echoing attribute in the final value to equal
+// the value the original target held at the echo's input path.
+func (g *ValueGenerator) resolveEchoes(value, target cty.Value) (cty.Value, tfdiags.Diagnostics) {
+	var diags tfdiags.Diagnostics
+
+	result, err := cty.Transform(value, func(path cty.Path, output cty.Value) (cty.Value, error) {
+		inputPath, ok := g.opts.Echoes[fmtPath(path)]
+		if !ok {
+			return output, nil
+		}
+
+		input, found := valueAtPath(target, attrPath(inputPath))
+		if !found {
+			diags = diags.Append(tfdiags.AttributeValue(
+				tfdiags.Error,
+				"Failed to echo attribute",
+				fmt.Sprintf("Terraform could not echo %s into %s because the target has no value at the input path.", inputPath, fmtPath(path)),
+				path))
+			return output, nil
+		}
+
+		if converted, err := convert.Convert(input, output.Type()); err != nil {
+			diags = diags.Append(tfdiags.AttributeValue(
+				tfdiags.Error,
+				"Failed to echo attribute",
+				fmt.Sprintf("Terraform could not echo %s into %s: %s.", inputPath, fmtPath(path), err),
+				path))
+			return output, nil
+		} else {
+			return converted, nil
+		}
+	})
+	if err != nil {
+		// We never return an error from the transform function, so this
+		// can't happen in practice.
+		panic(err)
+	}
+	return result, diags
+}
+
+// resolveLinks rewrites every linked attribute in the final value to equal
+// the value at its link's source path.
+//
+// Links named by the LinkOrder option resolve first, one at a time in the
+// declared order, with each step seeing the results of the steps before it.
+// That lets a chain of links (c linking to b linking to a) come out
+// consistent; in the single unordered pass every link would read the value
+// its source held before any links resolved.
+func (g *ValueGenerator) resolveLinks(value cty.Value) (cty.Value, tfdiags.Diagnostics) {
+	var diags tfdiags.Diagnostics
+
+	ordered := make(map[string]bool, len(g.opts.LinkOrder))
+	for _, rendered := range g.opts.LinkOrder {
+		source, ok := g.opts.Links[rendered]
+		if !ok {
+			continue
+		}
+		ordered[rendered] = true
+
+		var stepDiags tfdiags.Diagnostics
+		value, stepDiags = g.resolveLinkSet(value, map[string]string{rendered: source})
+		diags = diags.Append(stepDiags)
+	}
+
+	remaining := make(map[string]string, len(g.opts.Links))
+	for rendered, source := range g.opts.Links {
+		if !ordered[rendered] {
+			remaining[rendered] = source
+		}
+	}
+	if len(remaining) > 0 {
+		var restDiags tfdiags.Diagnostics
+		value, restDiags = g.resolveLinkSet(value, remaining)
+		diags = diags.Append(restDiags)
+	}
+	return value, diags
+}
+
+// resolveLinkSet resolves the given links in a single pass over the value.
+func (g *ValueGenerator) resolveLinkSet(value cty.Value, links map[string]string) (cty.Value, tfdiags.Diagnostics) {
+	var diags tfdiags.Diagnostics
+
+	result, err := cty.Transform(value, func(path cty.Path, target cty.Value) (cty.Value, error) {
+		sourcePath, ok := links[fmtPath(path)]
+		if !ok {
+			return target, nil
+		}
+
+		source, found := valueAtPath(value, attrPath(sourcePath))
+		if !found {
+			diags = diags.Append(tfdiags.AttributeValue(
+				tfdiags.Error,
+				"Failed to resolve linked attribute",
+				fmt.Sprintf("Terraform could not link %s to %s because there is no value at the source path.", fmtPath(path), sourcePath),
+				path))
+			return target, nil
+		}
+
+		if converted, err := convert.Convert(source, target.Type()); err != nil {
+			diags = diags.Append(tfdiags.AttributeValue(
+				tfdiags.Error,
+				"Failed to resolve linked attribute",
+				fmt.Sprintf("Terraform could not link %s to %s: %s.", fmtPath(path), sourcePath, err),
+				path))
+			return target, nil
+		} else {
+			return converted, nil
+		}
+	})
+	if err != nil {
+		// We never return an error from the transform function, so this
+		// can't happen in practice.
+		panic(err)
+	}
+	return result, diags
+}
+
+// anonymizeAttributes replaces the string value at every listed path with a
+// pseudonym derived from a hash of the value itself, so equal inputs always
+// produce the same pseudonym.
+func (g *ValueGenerator) anonymizeAttributes(value cty.Value) cty.Value {
+	result, err := cty.Transform(value, func(path cty.Path, target cty.Value) (cty.Value, error) {
+		rendered := fmtPath(path)
+		listed := false
+		for _, candidate := range g.opts.AnonymizeAttributes {
+			if candidate == rendered {
+				listed = true
+				break
+			}
+		}
+		if !listed || target.Type() != cty.String || target.IsNull() || !target.IsKnown() {
+			return target, nil
+		}
+
+		// Anonymization runs after marks are applied, and a marked value
+		// can't be read directly, so unmark, hash, and carry the marks over
+		// to the pseudonym.
+		unmarked, valueMarks := target.Unmark()
+
+		hash := fnv.New64a()
+		hash.Write([]byte(unmarked.AsString()))
+		return cty.StringVal(fmt.Sprintf("anon-%s", strFrom(NewPCGSource(hash.Sum64()), 8))).WithMarks(valueMarks), nil
+	})
+	if err != nil {
+		// We never return an error from the transform function, so this
+		// can't happen in practice.
+		panic(err)
+	}
+	return result
+}
+
+// resolveDerivedBools rewrites every derived bool attribute in the final
+// value to the bool derived from a hash of the value at its source path, so
+// equal source values always derive the same bool.
+func (g *ValueGenerator) resolveDerivedBools(value cty.Value) (cty.Value, tfdiags.Diagnostics) {
+	var diags tfdiags.Diagnostics
+
+	result, err := cty.Transform(value, func(path cty.Path, target cty.Value) (cty.Value, error) {
+		sourcePath, ok := g.opts.DerivedBools[fmtPath(path)]
+		if !ok || target.Type() != cty.Bool {
+			return target, nil
+		}
+
+		source, found := valueAtPath(value, attrPath(sourcePath))
+		if !found {
+			diags = diags.Append(tfdiags.AttributeValue(
+				tfdiags.Error,
+				"Failed to derive boolean attribute",
+				fmt.Sprintf("Terraform could not derive %s from %s because there is no value at the source path.", fmtPath(path), sourcePath),
+				path))
+			return target, nil
+		}
+
+		hash := fnv.New64a()
+		hash.Write([]byte(canonicalString(source)))
+		return cty.BoolVal(hash.Sum64()&1 == 1), nil
+	})
+	if err != nil {
+		// We never return an error from the transform function, so this
+		// can't happen in practice.
+		panic(err)
+	}
+	return result, diags
+}
+
+// attrPath parses a dotted attribute path, as rendered by fmtPath, back
+// into a cty.Path of attribute steps.
+func attrPath(rendered string) cty.Path {
+	var path cty.Path
+	for _, name := range strings.Split(rendered, ".") {
+		path = path.GetAttr(name)
+	}
+	return path
+}
+
+// TypeTransform pairs a cty.Type with a function that rewrites values of
+// that type. We use a slice of these rather than a map keyed by cty.Type
+// because structural types are not comparable and would panic as map keys.
+type TypeTransform struct {
+	Type      cty.Type
+	Transform func(cty.Value) cty.Value
+}
+
+// applyTypeTransforms rewrites every known, non-null value within the given
+// value whose type exactly matches one of the configured transforms. The
+// transform is expected to return a value of the same type.
+func (g *ValueGenerator) applyTypeTransforms(value cty.Value) cty.Value {
+	value, err := cty.Transform(value, func(path cty.Path, value cty.Value) (cty.Value, error) {
+		if value.IsNull() || !value.IsKnown() {
+			return value, nil
+		}
+
+		for _, transform := range g.opts.TypeTransforms {
+			if transform.Type.Equals(value.Type()) {
+				return transform.Transform(value), nil
+			}
+		}
+		return value, nil
+	})
+	if err != nil {
+		// We never return an error from the transform function, so this
+		// can't happen in practice.
+		panic(err)
+	}
+	return value
+}
+
+// shuffleLists reorders the elements of every list the ShuffleLists option
+// names, using a Fisher-Yates shuffle driven by the generator's random
+// source.
+func (g *ValueGenerator) shuffleLists(value cty.Value) cty.Value {
+	result, err := cty.Transform(value, func(path cty.Path, target cty.Value) (cty.Value, error) {
+		for _, listPath := range g.opts.ShuffleLists {
+			if fmtPath(path) != listPath {
+				continue
+			}
+			if target.IsNull() || !target.IsKnown() || !target.Type().IsListType() {
+				return target, nil
+			}
+
+			// The list may carry marks (from a sensitive predicate, say),
+			// and a marked value can't be measured or iterated directly, so
+			// shuffle the unmarked list and carry the marks over.
+			unmarked, valueMarks := target.Unmark()
+			if unmarked.LengthInt() < 2 {
+				return target, nil
+			}
+
+			elems := unmarked.AsValueSlice()
+			for ix := len(elems) - 1; ix > 0; ix-- {
+				g.recordRNGCall()
+				jx := g.rand().Intn(ix + 1)
+				elems[ix], elems[jx] = elems[jx], elems[ix]
+			}
+			return cty.ListVal(elems).WithMarks(valueMarks), nil
+		}
+		return target, nil
+	})
+	if err != nil {
+		// We never return an error from the transform function, so this
+		// can't happen in practice.
+		panic(err)
+	}
+	return result
+}
+
+// checkUniqueListElements verifies that the list at the given path (as
+// rendered by fmtPath) within the final value contains no two identical
+// elements, reporting an error diagnostic for each duplicate found.
+func checkUniqueListElements(value cty.Value, rendered string) tfdiags.Diagnostics {
+	var diags tfdiags.Diagnostics
+
+	listPath := attrPath(rendered)
+	list, ok := valueAtPath(value, listPath)
+	if !ok || list.IsNull() || !list.IsKnown() {
+		// Nothing at this path to check. The caller may have specified a
+		// path that only exists for some targets, so this isn't an error.
+		return diags
+	}
+
+	// A marked list can't be iterated directly, but its elements still need
+	// checking, so strip the marks first.
+	list, _ = list.Unmark()
+	if !list.CanIterateElements() {
+		return diags
+	}
+
+	seen := make(map[string]int)
+	index := 0
+	for it := list.ElementIterator(); it.Next(); {
+		_, element := it.Element()
+		canonical := canonicalString(element)
+		if first, ok := seen[canonical]; ok {
+			diags = diags.Append(tfdiags.AttributeValue(
+				tfdiags.Error,
+				"Duplicate list elements",
+				fmt.Sprintf("The elements at indices %d and %d of %s are identical, but the generation options require the elements of this list to be unique.", first, index, rendered),
+				listPath))
+		} else {
+			seen[canonical] = index
+		}
+		index++
+	}
+
+	return diags
+}
+
+// checkDistinctAttributes verifies that no two of the attributes listed in
+// DistinctAttributes hold the same value within the final object, reporting
+// an error diagnostic for each collision.
+func (g *ValueGenerator) checkDistinctAttributes(value cty.Value) tfdiags.Diagnostics {
+	var diags tfdiags.Diagnostics
+
+	seen := make(map[string]string)
+	for _, rendered := range g.opts.DistinctAttributes {
+		attr, ok := valueAtPath(value, attrPath(rendered))
+		if !ok || attr.IsNull() || !attr.IsKnown() {
+			// Nothing usable at this path to compare; absent and null
+			// attributes can't meaningfully collide.
+			continue
+		}
+
+		canonical := canonicalString(attr)
+		if first, collides := seen[canonical]; collides {
+			diags = diags.Append(tfdiags.AttributeValue(
+				tfdiags.Error,
+				"Computed values are not distinct",
+				fmt.Sprintf("The values of %s and %s are identical, but the generation options require the listed attributes to be mutually distinct.", first, rendered),
+				attrPath(rendered)))
+		} else {
+			seen[canonical] = rendered
+		}
+	}
+
+	return diags
+}
+
+// absentAttributes returns the names of the top-level schema attributes and
+// block types the target object holds no key for, in lexical order, or nil
+// when the target covers them all.
+func absentAttributes(target cty.Value, schema *configschema.Block) []string {
+	if !target.Type().IsObjectType() {
+		return nil
+	}
+
+	var absent []string
+	for name := range schema.ImpliedType().AttributeTypes() {
+		if !target.Type().HasAttribute(name) {
+			absent = append(absent, name)
+		}
+	}
+	sort.Strings(absent)
+	return absent
+}
+
+// conformanceProblems checks whether each attribute of the given value could
+// convert to the type the second schema implies for it, returning a sorted
+// description of every attribute that couldn't. Attributes only the second
+// schema has are not problems: decoding against the new schema would simply
+// fill them with null.
+func conformanceProblems(value cty.Value, schema *configschema.Block) []string {
+	var problems []string
+
+	if !value.Type().IsObjectType() {
+		return []string{fmt.Sprintf("the value is %s, not an object", value.Type().FriendlyName())}
+	}
+
+	for name, attrType := range schema.ImpliedType().AttributeTypes() {
+		if !value.Type().HasAttribute(name) {
+			continue
+		}
+		if _, err := convert.Convert(value.GetAttr(name), attrType); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %s", name, err))
+		}
+	}
+
+	sort.Strings(problems)
+	return problems
+}
+
+// constraintSkips returns true if the attribute at the given path belongs to
+// an ExactlyOneOf or ConflictsWith group and is not the member of that group
+// that should receive a value. The chosen member is the first one the target
+// already holds a value for, falling back to the first member listed.
+func (g *ValueGenerator) constraintSkips(root cty.Value, path cty.Path) bool {
+	rendered := fmtPath(path)
+
+	skips := func(groups [][]string) bool {
+		for _, group := range groups {
+			member := false
+			for _, candidate := range group {
+				if candidate == rendered {
+					member = true
+					break
+				}
+			}
+			if !member || len(group) == 0 {
+				continue
+			}
+
+			chosen := group[0]
+			for _, candidate := range group {
+				if value, ok := valueAtPath(root, attrPath(candidate)); ok && value != cty.NilVal && !value.IsNull() {
+					chosen = candidate
+					break
+				}
+			}
+			if chosen != rendered {
+				return true
+			}
+		}
+		return false
+	}
+
+	return skips(g.opts.ExactlyOneOf) || skips(g.opts.ConflictsWith)
+}
+
+// computedFlagWarnings compares the computed flag of every attribute the
+// current schema shares with the prior schema, returning a warning for each
+// attribute the two disagree on. The walk descends into nested-type
+// attributes and nested blocks; attributes only one schema has are skipped,
+// since there is nothing to disagree about.
+func computedFlagWarnings(prior, current *configschema.Block, path cty.Path) tfdiags.Diagnostics {
+	var diags tfdiags.Diagnostics
+
+	for name, attribute := range current.Attributes {
+		before, ok := prior.Attributes[name]
+		if !ok {
+			continue
+		}
+		attrPath := path.GetAttr(name)
+
+		switch {
+		case attribute.Computed && !before.Computed:
+			diags = diags.Append(tfdiags.AttributeValue(
+				tfdiags.Warning,
+				"Attribute is now computed",
+				fmt.Sprintf("The schema the target was produced under did not declare %s as computed, but the current schema does. Any value the target holds for it will be treated as settled rather than regenerated.", fmtPath(attrPath)),
+				attrPath))
+		case !attribute.Computed && before.Computed:
+			diags = diags.Append(tfdiags.AttributeValue(
+				tfdiags.Warning,
+				"Attribute is no longer computed",
+				fmt.Sprintf("The schema the target was produced under declared %s as computed, but the current schema does not. The value the target holds for it was generated under the old schema and will be kept as it is.", fmtPath(attrPath)),
+				attrPath))
+		}
+
+		if attribute.NestedType != nil && before.NestedType != nil {
+			diags = diags.Append(computedFlagWarnings(
+				&configschema.Block{Attributes: before.NestedType.Attributes},
+				&configschema.Block{Attributes: attribute.NestedType.Attributes},
+				attrPath))
+		}
+	}
+
+	for name, block := range current.BlockTypes {
+		before, ok := prior.BlockTypes[name]
+		if !ok {
+			continue
+		}
+		diags = diags.Append(computedFlagWarnings(&before.Block, &block.Block, path.GetAttr(name)))
+	}
+
+	return diags
+}
+
+// ambiguousOverrideKeys reports an error for every set of override keys that
+// normalise to the same name under case-insensitive matching. Which of the
+// colliding keys the loose lookup would pick is undefined, so the ambiguity
+// has to be resolved by the override's author rather than tolerated.
+func ambiguousOverrideKeys(with ReplacementValue) tfdiags.Diagnostics {
+	var diags tfdiags.Diagnostics
+
+	if with.Value == cty.NilVal {
+		return diags
+	}
+
+	cty.Walk(with.Value, func(path cty.Path, value cty.Value) (bool, error) {
+		if value.IsNull() || !value.Type().IsObjectType() {
+			return true, nil
+		}
+
+		groups := make(map[string][]string)
+		for name := range value.Type().AttributeTypes() {
+			normalized := normalizeAttributeName(name)
+			groups[normalized] = append(groups[normalized], name)
+		}
+
+		var normalized []string
+		for key, names := range groups {
+			if len(names) > 1 {
+				normalized = append(normalized, key)
+			}
+		}
+		sort.Strings(normalized)
+
+		for _, key := range normalized {
+			names := groups[key]
+			sort.Strings(names)
+			diags = diags.Append(withCause(tfdiags.AttributeValue(
+				tfdiags.Error,
+				"Ambiguous override keys",
+				fmt.Sprintf("The replacement value defined within %s supplies the keys %s, which all match the attribute %q under case-insensitive matching. It is undefined which of them would apply, so rename or remove the duplicates.", with.Range, strings.Join(names, ", "), key),
+				path), CauseOverride))
+		}
+		return true, nil
+	})
+
+	return diags
+}
+
+// requiredWithWarnings checks the override against each RequiredWith group,
+// returning a warning for every group the override covers only partially.
+func (g *ValueGenerator) requiredWithWarnings(with ReplacementValue) tfdiags.Diagnostics {
+	var diags tfdiags.Diagnostics
+
+	if with.Value == cty.NilVal {
+		return diags
+	}
+
+	for _, group := range g.opts.RequiredWith {
+		var set, missing []string
+		for _, candidate := range group {
+			if value, ok := valueAtPath(with.Value, attrPath(candidate)); ok && !value.IsNull() {
+				set = append(set, candidate)
+			} else {
+				missing = append(missing, candidate)
+			}
+		}
+
+		if len(set) > 0 && len(missing) > 0 {
+			diags = diags.Append(withCause(tfdiags.Sourceless(
+				tfdiags.Warning,
+				"Override sets only part of a required group",
+				fmt.Sprintf("The replacement value defined within %s sets %s but not %s, and the provider declares these attributes as required together. The missing attributes will receive generated values, which the provider would not accept alongside the overridden ones.", with.Range, strings.Join(set, ", "), strings.Join(missing, ", "))), CauseOverride))
+		}
+	}
+
+	return diags
+}
+
+// duplicateSetElements reports an error diagnostic when a set-typed override
+// supplied duplicate elements. Converting a list or tuple override into a set
+// silently collapses identical elements, so the generated set ends up smaller
+// than what the author wrote; that is almost always a mistake in the override
+// rather than intentional.
+func duplicateSetElements(converted, with cty.Value, replacement ReplacementValue, path cty.Path) tfdiags.Diagnostics {
+	var diags tfdiags.Diagnostics
+
+	if !converted.Type().IsSetType() || !converted.IsKnown() || !with.IsKnown() || !with.CanIterateElements() {
+		return diags
+	}
+
+	if supplied, distinct := with.LengthInt(), converted.LengthInt(); distinct < supplied {
+		diags = diags.Append(withCause(tfdiags.AttributeValue(
+			tfdiags.Error,
+			"Duplicate set elements in override",
+			fmt.Sprintf("The replacement value for %s defined within %s supplies %d elements but only %d are distinct, so the duplicates were dropped when building the set.", fmtPath(path), replacement.Range, supplied, distinct),
+			path), CauseOverride))
+	}
+
+	return diags
+}
+
+// rangeViolation reports an error diagnostic when an override supplies a
+// number outside the bounds declared for its path.
+func (g *ValueGenerator) rangeViolation(value cty.Value, replacement ReplacementValue, path cty.Path) tfdiags.Diagnostics {
+	var diags tfdiags.Diagnostics
+
+	bounds, ok := g.opts.NumberRanges[fmtPath(path)]
+	if !ok || value.Type() != cty.Number || value.IsNull() || !value.IsKnown() {
+		return diags
+	}
+
+	if value.LessThan(cty.NumberIntVal(bounds.Min)).True() || value.GreaterThan(cty.NumberIntVal(bounds.Max)).True() {
+		diags = diags.Append(withCause(tfdiags.AttributeValue(
+			tfdiags.Error,
+			"Override out of range",
+			fmt.Sprintf("The replacement value for %s defined within %s is %s, but the declared range for this attribute is %d to %d inclusive.", fmtPath(path), replacement.Range, value.AsBigFloat().String(), bounds.Min, bounds.Max),
+			path), CauseOverride))
+	}
+
+	return diags
+}
+
+// downgradeForProtocol rewrites a value so it only uses features available
+// in plugin protocol versions before 6: all marks are removed, and unknown
+// values lose any refinements they carried.
+func downgradeForProtocol(value cty.Value) cty.Value {
+	value, _ = value.UnmarkDeep()
+	value, err := cty.Transform(value, func(path cty.Path, value cty.Value) (cty.Value, error) {
+		if !value.IsKnown() {
+			// A fresh unknown of the same type carries no refinements.
+			return cty.UnknownVal(value.Type()), nil
+		}
+		return value, nil
+	})
+	if err != nil {
+		// We never return an error from the transform function, so this
+		// can't happen.
+		return value
+	}
+	return value
+}
+
+// validateOverrideDepth walks the replacement value alongside the schema and
+// reports any override value that is an object where the schema expects a
+// primitive value, which means the override nests more deeply than the schema
+// allows.
+func validateOverrideDepth(with ReplacementValue, schema *configschema.Block) tfdiags.Diagnostics {
+	var diags tfdiags.Diagnostics
+
+	if with.Value == cty.NilVal || !with.Value.Type().IsObjectType() {
+		// Nothing to check; an entirely invalid replacement value is
+		// reported elsewhere.
+		return diags
+	}
+
+	var checkAttribute func(value cty.Value, attribute *configschema.Attribute, path cty.Path)
+	var checkAttributes func(value cty.Value, attributes map[string]*configschema.Attribute, blocks map[string]*configschema.NestedBlock, path cty.Path)
+
+	checkAttribute = func(value cty.Value, attribute *configschema.Attribute, path cty.Path) {
+		if !value.Type().IsObjectType() || value.IsNull() {
+			return
+		}
+
+		if attribute.NestedType != nil {
+			checkAttributes(value, attribute.NestedType.Attributes, nil, path)
+			return
+		}
+
+		if attribute.Type.IsPrimitiveType() {
+			diags = diags.Append(tfdiags.AttributeValue(
+				tfdiags.Error,
+				"Invalid replacement value",
+				fmt.Sprintf("The replacement value defined at %s within %s nests more deeply than the schema allows: %s is %s, not an object.", fmtPath(path), with.Range, fmtPath(path), attribute.Type.FriendlyName()),
+				path))
+		}
+	}
+
+	checkAttributes = func(value cty.Value, attributes map[string]*configschema.Attribute, blocks map[string]*configschema.NestedBlock, path cty.Path) {
+		for name := range value.Type().AttributeTypes() {
+			childPath := path.GetAttr(name)
+			child := value.GetAttr(name)
+
+			if attribute, ok := attributes[name]; ok {
+				checkAttribute(child, attribute, childPath)
+				continue
+			}
+
+			if block, ok := blocks[name]; ok && child.Type().IsObjectType() && !child.IsNull() {
+				checkAttributes(child, block.Block.Attributes, block.Block.BlockTypes, childPath)
+			}
+
+			// Keys that match nothing in the schema are simply ignored, as
+			// they are during generation.
+		}
+	}
+
+	checkAttributes(with.Value, schema.Attributes, schema.BlockTypes, nil)
+	return diags
+}
+
+// expandGroupAttributes replaces null values for nested-type attributes that
+// use group nesting with an object whose fields are all null, mirroring the
+// way Terraform decodes NestingGroup blocks. The returned value is otherwise
+// identical to the target.
+func expandGroupAttributes(target cty.Value, schema *configschema.Block) cty.Value {
+	value, err := cty.Transform(target, func(path cty.Path, target cty.Value) (cty.Value, error) {
+		attribute := schema.AttributeByPath(path)
+		if attribute == nil || attribute.NestedType == nil {
+			return target, nil
+		}
+
+		if attribute.NestedType.Nesting == configschema.NestingGroup && target.IsNull() {
+			return nullObjectForNestedType(attribute.NestedType), nil
+		}
+		return target, nil
+	})
+	if err != nil {
+		// We never return an error from the transform function, so this
+		// can't happen.
+		return target
+	}
+	return value
+}
+
+// nullObjectForNestedType builds the value that represents an absent instance
+// of the given nested object type: an object with a null value for every
+// attribute, except that nested groups are themselves expanded recursively.
+func nullObjectForNestedType(obj *configschema.Object) cty.Value {
+	children := make(map[string]cty.Value)
+	for name, attribute := range obj.Attributes {
+		switch {
+		case attribute.NestedType != nil && attribute.NestedType.Nesting == configschema.NestingGroup:
+			children[name] = nullObjectForNestedType(attribute.NestedType)
+		case attribute.NestedType != nil:
+			children[name] = cty.NullVal(attribute.NestedType.ImpliedType())
+		default:
+			children[name] = cty.NullVal(attribute.Type)
+		}
+	}
+	return cty.ObjectVal(children)
+}
+
+// errFailFast is the sentinel used to stop the generation walk once a
+// FailFast operation has its first error in hand.
+var errFailFast = errors.New("fail fast")
+
+// defaultMaxDepth is the nesting depth limit applied when the MaxDepth
+// option is left at zero. Ten levels is comfortably beyond any sensible
+// provider schema.
+const defaultMaxDepth = 10
+
+// maxDepth returns the effective nesting depth limit for this generator.
+func (g *ValueGenerator) maxDepth() int {
+	if g.opts.MaxDepth > 0 {
+		return g.opts.MaxDepth
+	}
+	return defaultMaxDepth
+}
+
+// makeKnown returns the populateValue function which replaces a computed
+// value either with the given replacement value or, failing that, a value
+// generated by this generator.
+func (g *ValueGenerator) makeKnown(replacement ReplacementValue) populateValue {
+	inner := g.makeKnownValue(replacement)
+	return func(target, with cty.Value, path cty.Path) (cty.Value, tfdiags.Diagnostics) {
+		if !target.IsKnown() {
+			switch g.unknownPolicyFor(path) {
+			case UnknownPolicyPreserve:
+				return target, nil
+			case UnknownPolicyError:
+				var diags tfdiags.Diagnostics
+				diags = diags.Append(tfdiags.AttributeValue(
+					tfdiags.Error,
+					"Unexpected unknown value",
+					fmt.Sprintf("The value for %s was still unknown during apply, and the generation options forbid converting it to a known value.", fmtPath(path)),
+					path))
+				return target, diags
+			}
+		}
+
+		// An explicit override is the caller's choice and takes precedence
+		// over both of the prior-state shortcuts below.
+		if with == cty.NilVal && g.opts.Prior != cty.NilVal && g.isIncrementing(path) {
+			// Counters move forward from the prior state rather than being
+			// invented fresh on every update.
+			if prior, ok := valueAtPath(g.opts.Prior, path); ok && !prior.IsNull() && prior.IsKnown() && prior.Type() == cty.Number {
+				return prior.Add(cty.NumberIntVal(1)), nil
+			}
+		}
+
+		if with == cty.NilVal && g.opts.Prior != cty.NilVal && (g.isImmutable(path) || g.isOptionalComputed(path)) {
+			// The caller flagged this attribute as immutable, so an update
+			// must carry the prior value forward rather than inventing a
+			// new one. Optional computed attributes get the same treatment:
+			// when the configuration leaves one null, regenerating it on
+			// every operation would show a spurious diff against the prior
+			// state, so the prior value wins.
+			if prior, ok := valueAtPath(g.opts.Prior, path); ok && !prior.IsNull() {
+				return prior, nil
+			}
+		}
+
+		if with == cty.NilVal && (g.opts.RequireExplicitOverrides || g.opts.OverridesMustBeComplete) {
+			// The caller doesn't want us to invent values, so the attribute
+			// stays untouched. With OverridesMustBeComplete we also remember
+			// the path so the operation can report everything that was
+			// missed in one diagnostic.
+			if g.opts.OverridesMustBeComplete {
+				g.missingOverrides = append(g.missingOverrides, fmtPath(path))
+			}
+			return target, nil
+		}
+		return inner(target, with, path)
+	}
+}
+
+// makeKnownValue returns the function which builds the known value for a
+// single computed attribute, recursing through object types as needed.
+func (g *ValueGenerator) makeKnownValue(replacement ReplacementValue) populateValue {
+	var makeKnown func(target, with cty.Value, path cty.Path) (cty.Value, tfdiags.Diagnostics)
+	makeKnown = func(target, with cty.Value, path cty.Path) (cty.Value, tfdiags.Diagnostics) {
+		var diags tfdiags.Diagnostics
+
+		if with != cty.NilVal && !with.IsNull() {
+			// Then we have a pre-made value to replace it with. We'll make sure it
+			// is compatible with a conversion, and then just return it in place.
+			//
+			// Null replacement values don't count: an override that supplies
+			// only some fields of a nested object leaves the others null, and
+			// those should be filled by generation just as if the override
+			// hadn't mentioned them at all.
+
+			if value, err := convert.Convert(with, target.Type()); err != nil {
+				if g.opts.CoerceJSONOverrides && with.Type() == cty.String {
+					// The override might be a JSON document describing the
+					// value rather than the value itself, so give that a try
+					// before reporting the mismatch.
+					if coerced, jsonErr := ctyjson.Unmarshal([]byte(with.AsString()), target.Type()); jsonErr == nil {
+						return coerced, diags
+					}
+				}
+
+				diags = diags.Append(withCause(tfdiags.AttributeValue(
+					tfdiags.Error,
+					"Failed to replace target attribute",
+					fmt.Sprintf("Terraform could not replace the target type %s with the replacement value defined at %s within %s: %s.", target.Type().FriendlyName(), fmtPath(path), replacement.Range, err),
+					path), CauseOverride))
+
+				// We still want to return a valid value here. If the conversion did
+				// not work we carry on and just create a value instead. We've made
+				// a note of the diagnostics tracking why it didn't work so the
+				// overall operation will still fail, but we won't crash later on
+				// because of an unknown value or something.
+
+			} else {
+				// Successful conversion! We can just return the new value.
+				diags = diags.Append(duplicateSetElements(value, with, replacement, path))
+				diags = diags.Append(g.rangeViolation(value, replacement, path))
+				return value, diags
+			}
+		}
+
+		if pool, ok := g.opts.ValuePools[fmtPath(path)]; ok && len(pool) > 0 {
+			next := g.nextPoolValue(fmtPath(path), pool)
+			if value, err := convert.Convert(next, target.Type()); err != nil {
+				diags = diags.Append(tfdiags.AttributeValue(
+					tfdiags.Error,
+					"Failed to apply value pool",
+					fmt.Sprintf("Terraform could not convert the next pool value for %s to %s: %s.", fmtPath(path), target.Type().FriendlyName(), err),
+					path))
+
+				// As with failed overrides, we fall through and generate a
+				// value anyway so the overall result stays valid.
+
+			} else {
+				return value, diags
+			}
+		}
+
+		// Otherwise, we'll have to generate some values.
+		// Collections just get empty values of their type, while primitives
+		// are generated randomly: strings get 8 random alphanumeric
+		// characters, numbers a random integer below one million, and
+		// booleans a coin flip. Objects need to be valid types, so we recurse
+		// through the attributes and recursively call this function to
+		// generate values for each attribute.
+
+		switch {
+		case target.Type().IsPrimitiveType():
+			switch target.Type() {
+			case cty.String:
+				for _, emptyPath := range g.opts.EmptyStringPaths {
+					if fmtPath(path) == emptyPath {
+						return g.generatedString("", path, diags)
+					}
+				}
+				if template, ok := g.opts.Templates[fmtPath(path)]; ok {
+					return g.generatedString(g.renderTemplate(template), path, diags)
+				}
+				if length, ok := g.opts.Base64Lengths[fmtPath(path)]; ok && length > 0 {
+					g.recordRNGCall()
+					data := make([]byte, length)
+					for ix := range data {
+						data[ix] = byte(g.rand().Intn(256))
+					}
+					return g.generatedString(base64.StdEncoding.EncodeToString(data), path, diags)
+				}
+				if jsonSchema, ok := g.opts.JSONSchemas[fmtPath(path)]; ok {
+					if document, err := g.generateJSONDocument(jsonSchema); err == nil {
+						return g.generatedString(document, path, diags)
+					}
+
+					// A schema whose enum values can't serialise falls through
+					// to the plain random string, which at least keeps the
+					// value valid.
+				}
+				if length, ok := g.opts.StringLengths[fmtPath(path)]; ok {
+					if length > maxGeneratedStringLength {
+						diags = diags.Append(tfdiags.AttributeValue(
+							tfdiags.Error,
+							"Requested mock string too large",
+							fmt.Sprintf("The generation options request a %d byte string for %s, which is over the hard cap of %d bytes. A default length string was generated instead.", length, fmtPath(path), maxGeneratedStringLength),
+							path))
+					} else if length > 0 {
+						g.recordRNGCall()
+						return g.generatedString(strFrom(g.rand(), length), path, diags)
+					}
+				}
+				if g.opts.UseDescriptionHints {
+					if attribute := g.schema.AttributeByPath(path); attribute != nil {
+						if value, ok := generateForHint(g.rand(), descriptionHint(attribute.Description)); ok {
+							g.recordRNGCall()
+							return g.generatedString(value, path, diags)
+						}
+					}
+				}
+				if g.opts.StableIdentity != "" {
+					// Identity-keyed values bypass the shared random stream,
+					// so the same identity and path always yield the same
+					// value no matter which operation asks first.
+					return g.generatedString(stringForName(g.stableSourceFor(path), pathBaseName(path)), path, diags)
+				}
+				value := g.unreservedStringForName(pathBaseName(path))
+				if !target.IsKnown() {
+					// An unknown target may carry refinements from the plan,
+					// and the value we invent must not contradict them.
+					if prefix := target.Range().StringPrefix(); prefix != "" {
+						value = prefix + value
+					}
+				}
+				return g.generatedString(value, path, diags)
+			case cty.Number:
+				if choices := g.opts.NumberChoices[fmtPath(path)]; len(choices) > 0 {
+					g.recordRNGCall()
+					return cty.NumberIntVal(choices[g.rand().Intn(len(choices))]), diags
+				}
+				if bounds, ok := g.opts.NumberRanges[fmtPath(path)]; ok && bounds.Max >= bounds.Min {
+					g.recordRNGCall()
+					return cty.NumberIntVal(boundedInt64(g.rand(), bounds)), diags
+				}
+				g.recordRNGCall()
+				return cty.NumberIntVal(int64(g.rand().Intn(1000000))), diags
+			case cty.Bool:
+				g.recordRNGCall()
+				return cty.BoolVal(g.rand().Intn(2) == 1), diags
+			default:
+				panic(fmt.Errorf("unknown primitive type: %s", target.Type().FriendlyName()))
+			}
+		case target.Type().IsListType():
+			if min := g.opts.MinCollectionLengths[fmtPath(path)]; min > 0 {
+				elements := make([]cty.Value, min)
+				for ix := range elements {
+					element, elementDiags := makeKnown(cty.UnknownVal(target.Type().ElementType()), cty.NilVal, path.Index(cty.NumberIntVal(int64(ix))))
+					diags = diags.Append(elementDiags)
+					elements[ix] = element
+				}
+				return cty.ListVal(elements), diags
+			}
+			return cty.ListValEmpty(target.Type().ElementType()), diags
+		case target.Type().IsSetType():
+			if min := g.opts.MinCollectionLengths[fmtPath(path)]; min > 0 {
+				elements := make([]cty.Value, min)
+				for ix := range elements {
+					element, elementDiags := makeKnown(cty.UnknownVal(target.Type().ElementType()), cty.NilVal, path.Index(cty.NumberIntVal(int64(ix))))
+					diags = diags.Append(elementDiags)
+					elements[ix] = element
+				}
+
+				// Sets collapse identical elements, so an element type with
+				// fewer distinct values than the minimum (booleans, say)
+				// can't reach it.
+				set := cty.SetVal(elements)
+				if set.LengthInt() < min {
+					diags = diags.Append(tfdiags.AttributeValue(
+						tfdiags.Error,
+						"Could not generate enough distinct set elements",
+						fmt.Sprintf("The generation options request at least %d elements for %s, but only %d distinct values could be generated for its element type.", min, fmtPath(path), set.LengthInt()),
+						path))
+				}
+				return set, diags
+			}
+			if target.Type().ElementType() == cty.Number && g.opts.NumberSetLength > 0 {
+				set, setDiags := g.uniqueNumberSet(path)
+				diags = diags.Append(setDiags)
+				return set, diags
+			}
+			return cty.SetValEmpty(target.Type().ElementType()), diags
+		case target.Type().IsMapType():
+			if min := g.opts.MinCollectionLengths[fmtPath(path)]; min > 0 {
+				entries := make(map[string]cty.Value, min)
+				attempts := 0
+				for len(entries) < min {
+					if attempts >= maxDistinctDrawAttempts {
+						diags = diags.Append(g.distinctMapKeysError(min, path))
+						break
+					}
+
+					key := g.generatedMapKey()
+					if _, exists := entries[key]; exists {
+						attempts++
+						continue
+					}
+					attempts = 0
+
+					entry, entryDiags := makeKnown(cty.UnknownVal(target.Type().ElementType()), cty.NilVal, path.Index(cty.StringVal(key)))
+					diags = diags.Append(entryDiags)
+					entries[key] = entry
+				}
+				if len(entries) == 0 {
+					return cty.MapValEmpty(target.Type().ElementType()), diags
+				}
+				return cty.MapVal(entries), diags
+			}
+			return cty.MapValEmpty(target.Type().ElementType()), diags
+		case target.Type().IsTupleType():
+			types := target.Type().TupleElementTypes()
+			if len(types) == 0 {
+				return cty.EmptyTupleVal, diags
+			}
+
+			elements := make([]cty.Value, len(types))
+			for ix, elementType := range types {
+				element, elementDiags := makeKnown(cty.UnknownVal(elementType), cty.NilVal, path.Index(cty.NumberIntVal(int64(ix))))
+				diags = diags.Append(elementDiags)
+				elements[ix] = element
+			}
+			return cty.TupleVal(elements), diags
+		case target.Type().IsObjectType():
+			children := make(map[string]cty.Value)
+			for name, attribute := range target.Type().AttributeTypes() {
+				child, childDiags := makeKnown(cty.UnknownVal(attribute), cty.NilVal, path.GetAttr(name))
+				diags = diags.Append(childDiags)
+				children[name] = child
+			}
+			return cty.ObjectVal(children), diags
+		default:
+			panic(fmt.Errorf("unknown complex type: %s", target.Type().FriendlyName()))
+		}
+	}
+	return makeKnown
+}
+
+// nextPoolValue returns the next value from the given pool, cycling back to
+// the start once every value has been used.
+func (g *ValueGenerator) nextPoolValue(key string, pool []cty.Value) cty.Value {
+	if g.poolIndices == nil {
+		g.poolIndices = make(map[string]int)
+	}
+
+	value := pool[g.poolIndices[key]%len(pool)]
+	g.poolIndices[key]++
+	return value
+}
+
+// uniqueNumberSet generates a set of NumberSetLength distinct numbers. Sets
+// collapse duplicate elements silently, so we redraw on collision to make
+// sure the set really does end up with the requested length, giving up with
+// an error if a fresh value refuses to turn up.
+func (g *ValueGenerator) uniqueNumberSet(path cty.Path) (cty.Value, tfdiags.Diagnostics) {
+	var diags tfdiags.Diagnostics
+
+	seen := make(map[int]bool)
+	elements := make([]cty.Value, 0, g.opts.NumberSetLength)
+	attempts := 0
+	for len(elements) < g.opts.NumberSetLength {
+		if attempts >= maxDistinctDrawAttempts {
+			diags = diags.Append(tfdiags.AttributeValue(
+				tfdiags.Error,
+				"Could not generate enough distinct set elements",
+				fmt.Sprintf("The generation options request %d distinct numbers for %s, but the generator could not draw a fresh value after %d consecutive collisions.", g.opts.NumberSetLength, fmtPath(path), maxDistinctDrawAttempts),
+				path))
+			break
+		}
+
+		g.recordRNGCall()
+		n := g.rand().Intn(1000000)
+		if seen[n] {
+			attempts++
+			continue
+		}
+		attempts = 0
+		seen[n] = true
+		elements = append(elements, cty.NumberIntVal(int64(n)))
+	}
+
+	if len(elements) == 0 {
+		return cty.SetValEmpty(cty.Number), diags
+	}
+	return cty.SetVal(elements), diags
+}
+
+// boundedInt64 draws a random integer within the given inclusive bounds.
+// The width of the range can overflow int64 for extreme but still valid
+// bounds, so it is computed in uint64 space, and ranges too wide for a
+// single Intn call are served from a full 64-bit draw instead.
+func boundedInt64(source RandSource, bounds NumberRange) int64 {
+	width := uint64(bounds.Max) - uint64(bounds.Min) + 1
+
+	var offset uint64
+	switch {
+	case width == 0:
+		// The bounds span every int64 value, so any draw is in range.
+		offset = drawUint64(source)
+	case width <= math.MaxInt32:
+		offset = uint64(source.Intn(int(width)))
+	default:
+		// The modulo introduces a slight bias towards lower offsets, which
+		// is an acceptable trade for mocked values.
+		offset = drawUint64(source) % width
+	}
+
+	return int64(uint64(bounds.Min) + offset)
+}
+
+// drawUint64 assembles a uniformly distributed 64-bit value from three
+// smaller draws, keeping every Intn argument within the range of int on
+// 32-bit platforms.
+func drawUint64(source RandSource) uint64 {
+	return uint64(source.Intn(1<<31))<<33 | uint64(source.Intn(1<<31))<<2 | uint64(source.Intn(1<<2))
+}
+
+// renderTemplate expands the placeholders in a caller-supplied template. See
+// the Templates option for the placeholders we support.
+func (g *ValueGenerator) renderTemplate(template string) string {
+	if strings.Contains(template, "${random}") {
+		g.recordRNGCall()
+		template = strings.ReplaceAll(template, "${random}", strFrom(g.rand(), 8))
+	}
+	template = strings.ReplaceAll(template, "${type}", g.opts.ResourceType)
+	template = strings.ReplaceAll(template, "${each.key}", g.opts.EachKey)
+	return template
+}
+
+// unknownPolicyFor resolves the unknown value policy for the given path,
+// with any per-path policy taking precedence over the global one.
+func (g *ValueGenerator) unknownPolicyFor(path cty.Path) UnknownPolicy {
+	if policy, ok := g.opts.UnknownPolicyPerPath[fmtPath(path)]; ok {
+		return policy
+	}
+	return g.opts.UnknownPolicy
+}
+
+// configCollisionWarnings compares a freshly generated value against every
+// non-computed attribute value in the target, returning a warning for each
+// one it happens to match.
+func configCollisionWarnings(root cty.Value, schema *configschema.Block, path cty.Path, generated cty.Value) tfdiags.Diagnostics {
+	var diags tfdiags.Diagnostics
+
+	cty.Walk(root, func(other cty.Path, value cty.Value) (bool, error) {
+		attribute := schema.AttributeByPath(other)
+		if attribute == nil || attribute.Computed {
+			return true, nil
+		}
+
+		if value.IsKnown() && !value.IsNull() && value.RawEquals(generated) {
+			diags = diags.Append(tfdiags.AttributeValue(
+				tfdiags.Warning,
+				"Generated value matches configuration",
+				fmt.Sprintf("The value generated for %s is identical to the value of %s from the configuration. Anything comparing the two will pass by coincidence, which may hide an unintended coupling.", fmtPath(path), fmtPath(other)),
+				path))
+		}
+		return true, nil
+	})
+
+	return diags
+}
+
+// blockByPath resolves the nested block declared at the given path within
+// the schema, or nil if the path doesn't name a block. Index steps are
+// skipped, matching how attributes are resolved.
+func blockByPath(schema *configschema.Block, path cty.Path) *configschema.NestedBlock {
+	current := schema
+	var found *configschema.NestedBlock
+	for _, step := range path {
+		step, ok := step.(cty.GetAttrStep)
+		if !ok {
+			continue
+		}
+
+		block, ok := current.BlockTypes[step.Name]
+		if !ok {
+			return nil
+		}
+		found = block
+		current = &block.Block
+	}
+	return found
+}
+
+// generateMapBlockEntries invents the requested number of entries for an
+// empty NestingMap block, drawing a distinct random key for each and
+// populating the entry objects like any other generated value.
+func (g *ValueGenerator) generateMapBlockEntries(block *configschema.NestedBlock, count int, path cty.Path, populateValue populateValue) (cty.Value, tfdiags.Diagnostics) {
+	var diags tfdiags.Diagnostics
+
+	elementType := block.Block.ImpliedType()
+	entries := make(map[string]cty.Value, count)
+	attempts := 0
+	for len(entries) < count {
+		if attempts >= maxDistinctDrawAttempts {
+			diags = diags.Append(g.distinctMapKeysError(count, path))
+			break
+		}
+
+		key := g.generatedMapKey()
+		if _, exists := entries[key]; exists {
+			attempts++
+			continue
+		}
+		attempts = 0
+
+		entry, entryDiags := populateValue(cty.UnknownVal(elementType), cty.NilVal, path.Index(cty.StringVal(key)))
+		diags = diags.Append(entryDiags)
+		entries[key] = entry
+	}
+	if len(entries) == 0 {
+		return cty.MapValEmpty(elementType), diags
+	}
+	return cty.MapVal(entries), diags
+}
+
+// distinctMapKeysError is the diagnostic both map-entry loops report when
+// distinct generated keys refuse to turn up, most commonly because a
+// CanonicalizeMapKeys hook collapses the drawn keys together.
+func (g *ValueGenerator) distinctMapKeysError(want int, path cty.Path) tfdiags.Diagnostic {
+	return tfdiags.AttributeValue(
+		tfdiags.Error,
+		"Could not generate enough distinct map keys",
+		fmt.Sprintf("The generation options request %d entries for %s, but the generator could not draw a fresh key after %d consecutive collisions. A CanonicalizeMapKeys hook that collapses keys together can cause this.", want, fmtPath(path), maxDistinctDrawAttempts),
+		path)
+}
+
+// generatedMapKey draws a random key for an invented map entry, applying the
+// caller's canonicalizer when one is set.
+func (g *ValueGenerator) generatedMapKey() string {
+	g.recordRNGCall()
+	key := strFrom(g.rand(), 8)
+	if g.opts.CanonicalizeMapKeys != nil {
+		key = g.opts.CanonicalizeMapKeys(key)
+	}
+	return key
+}
+
+// elementIndex returns the position the given path points at within its
+// containing list or tuple, or false if the path doesn't end in a numeric
+// index step.
+func elementIndex(path cty.Path) (int, bool) {
+	if len(path) == 0 {
+		return 0, false
+	}
+
+	step, ok := path[len(path)-1].(cty.IndexStep)
+	if !ok || step.Key.Type() != cty.Number {
+		return 0, false
+	}
+
+	index, _ := step.Key.AsBigFloat().Int64()
+	return int(index), true
+}
+
+// innerComputedAttribute returns the computed attribute whose plain
+// object-typed value contains the given path, or nil if no prefix of the
+// path resolves to a computed attribute. Paths that resolve to an attribute
+// directly are not this function's business.
+func innerComputedAttribute(schema *configschema.Block, path cty.Path) *configschema.Attribute {
+	for end := len(path) - 1; end > 0; end-- {
+		if _, ok := path[end].(cty.GetAttrStep); !ok {
+			// We only descend through object attributes here; elements of
+			// collections and tuples have their own handling.
+			return nil
+		}
+
+		if attribute := schema.AttributeByPath(path[:end]); attribute != nil {
+			if attribute.Computed {
+				return attribute
+			}
+			return nil
+		}
+	}
+	return nil
+}
+
+// attributeForElement returns the collection-typed attribute containing the
+// element at the given path, or nil if the path doesn't point at an element
+// of an attribute. Trailing index steps are stripped so elements of nested
+// collections resolve to the attribute that declares them.
+func attributeForElement(schema *configschema.Block, path cty.Path) *configschema.Attribute {
+	end := len(path)
+	for end > 0 {
+		if _, ok := path[end-1].(cty.IndexStep); !ok {
+			break
+		}
+		end--
+	}
+
+	if end == len(path) {
+		// No index steps, so this isn't a collection element.
+		return nil
+	}
+	return schema.AttributeByPath(path[:end])
+}
+
+// oversizedSetPrefix walks the given path through root looking for a set with
+// more elements than MaxSetElements allows. It returns the path of the first
+// such set, so values inside it can be skipped and the set reported once.
+func (g *ValueGenerator) oversizedSetPrefix(root cty.Value, path cty.Path) (cty.Path, bool) {
+	current := root
+	for ix, step := range path {
+		if current.IsKnown() && !current.IsNull() && current.Type().IsSetType() && current.LengthInt() > g.opts.MaxSetElements {
+			return path[:ix], true
+		}
+
+		next, err := step.Apply(current)
+		if err != nil {
+			return nil, false
+		}
+		current = next
+	}
+	return nil, false
+}
+
+// requiredInsideOptionalObject returns true if the attribute at the given
+// path is a required, non-computed field directly inside an optional
+// nested-type attribute.
+func (g *ValueGenerator) requiredInsideOptionalObject(path cty.Path, attribute *configschema.Attribute) bool {
+	if !attribute.Required || attribute.Computed || len(path) < 2 || g.schema == nil {
+		return false
+	}
+
+	parent := g.schema.AttributeByPath(path[:len(path)-1])
+	return parent != nil && parent.Optional && parent.NestedType != nil
+}
+
+// isOptionalComputed returns true if the schema declares the attribute at the
+// given path as both optional and computed.
+func (g *ValueGenerator) isOptionalComputed(path cty.Path) bool {
+	if g.schema == nil {
+		return false
+	}
+
+	attribute := g.schema.AttributeByPath(path)
+	return attribute != nil && attribute.Optional && attribute.Computed
+}
+
+// isIncrementing returns true if the caller flagged the attribute at the
+// given path as an auto-incrementing counter.
+func (g *ValueGenerator) isIncrementing(path cty.Path) bool {
+	rendered := fmtPath(path)
+	for _, candidate := range g.opts.IncrementingAttributes {
+		if rendered == candidate {
+			return true
+		}
+	}
+	return false
+}
+
+// isImmutable returns true if the caller flagged the attribute at the given
+// path as immutable.
+func (g *ValueGenerator) isImmutable(path cty.Path) bool {
+	rendered := fmtPath(path)
+	for _, immutable := range g.opts.ImmutableAttributes {
+		if rendered == immutable {
+			return true
+		}
+	}
+	return false
+}
+
+// maxReservedAttempts bounds how often the generator will redraw to avoid a
+// reserved value before giving up and accepting the collision.
+const maxReservedAttempts = 10
+
+// maxValidationAttempts bounds how often the generator will redraw to
+// satisfy a caller-supplied validator before reporting an error.
+const maxValidationAttempts = 10
+
+// maxDistinctDrawAttempts bounds how many consecutive colliding draws the
+// generator tolerates while assembling a collection of distinct random
+// values, so a request for more values than the draw space can supply fails
+// with an error instead of looping forever.
+const maxDistinctDrawAttempts = 100
+
+// maxGeneratedStringLength is the hard cap on the byte size of a single
+// generated string, regardless of what the StringLengths option asks for.
+const maxGeneratedStringLength = 1024
+
+// unreservedStringForName generates a string for the attribute with the given
+// name, redrawing as needed to avoid the caller's reserved values and, if
+// GloballyUniqueStrings is set, any string this generator produced before.
+func (g *ValueGenerator) unreservedStringForName(name string) string {
+	value := g.stringForName(name)
+	for attempt := 0; (g.isReserved(value) || g.isAlreadyGenerated(value)) && attempt < maxReservedAttempts; attempt++ {
+		value = g.stringForName(name)
+	}
+
+	if g.opts.GloballyUniqueStrings {
+		if g.generatedStrings == nil {
+			g.generatedStrings = make(map[string]bool)
+		}
+		g.generatedStrings[value] = true
+	}
+	return value
+}
+
+// isAlreadyGenerated returns true if this generator produced the given string
+// before and the caller asked for globally unique strings.
+func (g *ValueGenerator) isAlreadyGenerated(value string) bool {
+	return g.opts.GloballyUniqueStrings && g.generatedStrings[value]
+}
+
+// isAdvisory returns true if the caller flagged the attribute at the given
+// path as one a generated placeholder is unlikely to satisfy.
+func (g *ValueGenerator) isAdvisory(path cty.Path) bool {
+	rendered := fmtPath(path)
+	for _, candidate := range g.opts.AdvisoryAttributes {
+		if candidate == rendered {
+			return true
+		}
+	}
+	return false
+}
+
+// isEphemeral returns true if the caller declared the attribute at the given
+// path as ephemeral.
+func (g *ValueGenerator) isEphemeral(path cty.Path) bool {
+	rendered := fmtPath(path)
+	for _, candidate := range g.opts.EphemeralAttributes {
+		if candidate == rendered {
+			return true
+		}
+	}
+	return false
+}
+
+// isReserved returns true if the given string is in the caller's list of
+// reserved values.
+func (g *ValueGenerator) isReserved(value string) bool {
+	for _, reserved := range g.opts.ReservedValues {
+		if value == reserved {
+			return true
+		}
+	}
+	return false
+}
+
+// stringForName generates a string for the attribute with the given name,
+// consulting the caller's Faker first if one was supplied.
+func (g *ValueGenerator) stringForName(name string) string {
+	if g.opts.Faker != nil {
+		if value, ok := fakeForName(g.opts.Faker, name); ok {
+			return value
+		}
+	}
+	if g.opts.Profile != "" {
+		if value, ok := profileStringForName(g.opts.Profile, g.rand(), name); ok {
+			g.recordRNGCall()
+			return value
+		}
+	}
+	if nameSuggestsTimestamp(name) {
+		return g.clock().Now().UTC().Format(time.RFC3339)
+	}
+	if g.opts.ModulePath != "" && nameSuggestsFormat(name, "id") {
+		g.recordRNGCall()
+		return fmt.Sprintf("%s:%s", g.opts.ModulePath, strFrom(g.rand(), 8))
+	}
+	g.recordRNGCall()
+	return stringForName(g.rand(), name)
+}
+
+// generatedString funnels a freshly invented string through the
+// ValidateGeneratedStrings guard and the byte accounting on its way to
+// becoming a value, so every generation branch gets the same treatment.
+func (g *ValueGenerator) generatedString(value string, path cty.Path, diags tfdiags.Diagnostics) (cty.Value, tfdiags.Diagnostics) {
+	diags = diags.Append(g.checkGeneratedString(value, path))
+	return cty.StringVal(g.recordStringBytes(value)), diags
+}
+
+// checkGeneratedString applies the ValidateGeneratedStrings guard to a
+// freshly generated string, reporting a diagnostic if the value is not valid
+// UTF-8 or strays outside the configured charset.
+func (g *ValueGenerator) checkGeneratedString(value string, path cty.Path) tfdiags.Diagnostics {
+	var diags tfdiags.Diagnostics
+
+	if !g.opts.ValidateGeneratedStrings {
+		return diags
+	}
+
+	if !utf8.ValidString(value) {
+		diags = diags.Append(tfdiags.AttributeValue(
+			tfdiags.Error,
+			"Generated string is not valid UTF-8",
+			fmt.Sprintf("The string generated for %s contains bytes that are not valid UTF-8. The random source or faker supplying generated values is misbehaving.", fmtPath(path)),
+			path))
+		return diags
+	}
+
+	if g.opts.Charset != "" {
+		for _, r := range value {
+			if !strings.ContainsRune(g.opts.Charset, r) {
+				diags = diags.Append(tfdiags.AttributeValue(
+					tfdiags.Error,
+					"Generated string outside allowed charset",
+					fmt.Sprintf("The string generated for %s contains %q, which is not in the configured charset. The random source or faker supplying generated values is misbehaving.", fmtPath(path), r),
+					path))
+				return diags
+			}
+		}
+	}
+
+	return diags
+}
+
+// stableSourceFor returns a random source seeded from the generator's stable
+// identity and the given path, so repeated calls for the same identity and
+// path draw the same sequence.
+func (g *ValueGenerator) stableSourceFor(path cty.Path) RandSource {
+	hash := fnv.New64a()
+	hash.Write([]byte(g.opts.StableIdentity))
+	hash.Write([]byte{0})
+	hash.Write([]byte(fmtPath(path)))
+	return NewPCGSource(hash.Sum64())
+}
+
+// rand returns the caller's random source, or the default source if they
+// didn't supply one.
+func (g *ValueGenerator) rand() RandSource {
+	if g.opts.Rand != nil {
+		return g.opts.Rand
+	}
+	return defaultSource{}
+}
+
+// clock returns the caller's clock, or the system clock if they didn't
+// supply one.
+func (g *ValueGenerator) clock() Clock {
+	if g.opts.Clock != nil {
+		return g.opts.Clock
+	}
+	return systemClock{}
+}
+
+// recordStringBytes adds the byte length of an invented string to the
+// report, if the caller requested one, and returns the string unchanged.
+func (g *ValueGenerator) recordStringBytes(value string) string {
+	if g.opts.Report != nil {
+		g.opts.Report.GeneratedStringBytes += len(value)
+	}
+	return value
+}
+
+// recordMark notes in the report, if the caller requested one, that the
+// generator applied the given mark at the given path.
+func (g *ValueGenerator) recordMark(path cty.Path, mark interface{}) {
+	if g.opts.Report == nil {
+		return
+	}
+	if g.opts.Report.AppliedMarks == nil {
+		g.opts.Report.AppliedMarks = make(map[string][]string)
+	}
+
+	rendered := fmtPath(path)
+	g.opts.Report.AppliedMarks[rendered] = append(g.opts.Report.AppliedMarks[rendered], fmt.Sprintf("%#v", mark))
+}
+
+// recordRNGCall notes in the report, if the caller requested one, that the
+// generator drew another value from its random source.
+func (g *ValueGenerator) recordRNGCall() {
+	if g.opts.Report != nil {
+		g.opts.Report.RNGCalls++
+	}
+}
+
+// collapseDiagnostics folds a set of diagnostics into a single diagnostic
+// whose detail lists the detail of each underlying diagnostic as a bullet
+// point. The collapsed diagnostic is an error if any of the originals were,
+// and a warning otherwise.
+func collapseDiagnostics(diags tfdiags.Diagnostics) tfdiags.Diagnostics {
+	if len(diags) == 0 {
+		return diags
+	}
+
+	severity := tfdiags.Warning
+	if diags.HasErrors() {
+		severity = tfdiags.Error
+	}
+
+	var detail strings.Builder
+	detail.WriteString("Terraform encountered the following problems while generating mock values:\n")
+	for _, diag := range diags {
+		fmt.Fprintf(&detail, "  - %s\n", diag.Description().Detail)
+	}
+
+	var collapsed tfdiags.Diagnostics
+	collapsed = collapsed.Append(tfdiags.Sourceless(
+		severity,
+		"Could not generate mock values",
+		detail.String()))
+	return collapsed
+}
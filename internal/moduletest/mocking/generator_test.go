@@ -0,0 +1,4359 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package mocking
+
+import (
+	"encoding/base64"
+	"math/rand"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/hashicorp/terraform/internal/addrs"
+	"github.com/hashicorp/terraform/internal/configs/configschema"
+	"github.com/hashicorp/terraform/internal/lang/marks"
+	"github.com/hashicorp/terraform/internal/tfdiags"
+)
+
+func TestValueGenerator_collapseDiagnostics(t *testing.T) {
+	// Two separate computed attributes, both with invalid replacement values,
+	// so we get two diagnostics to collapse.
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"first": {
+				Type:     cty.String,
+				Computed: true,
+			},
+			"second": {
+				Type:     cty.String,
+				Computed: true,
+			},
+		},
+	}
+
+	target := cty.ObjectVal(map[string]cty.Value{
+		"first":  cty.NullVal(cty.String),
+		"second": cty.NullVal(cty.String),
+	})
+
+	with := ReplacementValue{
+		Value: cty.ObjectVal(map[string]cty.Value{
+			"first":  cty.ListValEmpty(cty.String),
+			"second": cty.ListValEmpty(cty.String),
+		}),
+	}
+
+	testRand = rand.New(rand.NewSource(0))
+	defer func() {
+		testRand = nil
+	}()
+
+	generator := NewValueGenerator(GenerateOptions{
+		CollapseDiagnostics: true,
+	})
+
+	_, diags := generator.ComputedValuesForDataSource(target, with, schema)
+	if len(diags) != 1 {
+		t.Fatalf("expected exactly one diagnostic, got %d", len(diags))
+	}
+
+	if !diags.HasErrors() {
+		t.Errorf("expected the collapsed diagnostic to be an error")
+	}
+
+	detail := diags[0].Description().Detail
+	for _, attr := range []string{"first", "second"} {
+		if !strings.Contains(detail, attr) {
+			t.Errorf("expected collapsed detail to mention %q, but it did not:\n%s", attr, detail)
+		}
+	}
+
+	// Without the option, we should still get the separate diagnostics.
+	testRand = rand.New(rand.NewSource(0))
+	_, diags = ComputedValuesForDataSource(target, with, schema)
+	if len(diags) != 2 {
+		t.Fatalf("expected two separate diagnostics by default, got %d", len(diags))
+	}
+}
+
+func TestValueGenerator_sensitivePredicates(t *testing.T) {
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"id": {
+				Type:     cty.String,
+				Computed: true,
+			},
+			"secret": {
+				Type: cty.Bool,
+			},
+		},
+	}
+
+	generator := NewValueGenerator(GenerateOptions{
+		SensitivePredicates: map[string]func(cty.Value) bool{
+			"id": func(obj cty.Value) bool {
+				return obj.GetAttr("secret").True()
+			},
+		},
+	})
+
+	for _, secret := range []bool{true, false} {
+		testRand = rand.New(rand.NewSource(0))
+
+		target := cty.ObjectVal(map[string]cty.Value{
+			"id":     cty.NullVal(cty.String),
+			"secret": cty.BoolVal(secret),
+		})
+
+		actual, diags := generator.ComputedValuesForDataSource(target, ReplacementValue{}, schema)
+		if diags.HasErrors() {
+			t.Fatalf("unexpected errors: %s", diags.Err())
+		}
+
+		if actual.GetAttr("id").HasMark(marks.Sensitive) != secret {
+			t.Errorf("expected sensitive mark to be %t when secret is %t", secret, secret)
+		}
+	}
+
+	testRand = nil
+}
+
+// staticFaker implements Faker with fixed values, to prove the generator
+// prefers a caller-supplied faker over the built-in generators.
+type staticFaker struct{}
+
+func (staticFaker) Name() string     { return "Jamie Mock" }
+func (staticFaker) Email() string    { return "jamie@example.com" }
+func (staticFaker) Hostname() string { return "mock.example.com" }
+func (staticFaker) UUID() string     { return "123e4567-e89b-12d3-a456-426614174000" }
+
+func TestValueGenerator_faker(t *testing.T) {
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"name": {
+				Type:     cty.String,
+				Computed: true,
+			},
+			"email": {
+				Type:     cty.String,
+				Computed: true,
+			},
+			"uuid": {
+				Type:     cty.String,
+				Computed: true,
+			},
+			"id": {
+				Type:     cty.String,
+				Computed: true,
+			},
+		},
+	}
+
+	target := cty.ObjectVal(map[string]cty.Value{
+		"name":  cty.NullVal(cty.String),
+		"email": cty.NullVal(cty.String),
+		"uuid":  cty.NullVal(cty.String),
+		"id":    cty.NullVal(cty.String),
+	})
+
+	testRand = rand.New(rand.NewSource(0))
+	defer func() {
+		testRand = nil
+	}()
+
+	generator := NewValueGenerator(GenerateOptions{
+		Faker: staticFaker{},
+	})
+
+	actual, diags := generator.ComputedValuesForDataSource(target, ReplacementValue{}, schema)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+
+	expectations := map[string]string{
+		"name":  "Jamie Mock",
+		"email": "jamie@example.com",
+		"uuid":  "123e4567-e89b-12d3-a456-426614174000",
+	}
+	for attr, expected := range expectations {
+		if actual.GetAttr(attr).AsString() != expected {
+			t.Errorf("expected %s to come from the faker as %q, got %q", attr, expected, actual.GetAttr(attr).AsString())
+		}
+	}
+
+	// The faker doesn't know anything about plain ids, so those should still
+	// come from the built-in generator.
+	if id := actual.GetAttr("id").AsString(); len(id) != 8 {
+		t.Errorf("expected a plain 8 character string for id, got %q", id)
+	}
+}
+
+func TestValueGenerator_validateOverrideDepth(t *testing.T) {
+	target := cty.ObjectVal(map[string]cty.Value{
+		"id":    cty.NullVal(cty.String),
+		"value": cty.StringVal("Hello, world!"),
+	})
+
+	// The id attribute is a plain string, but the override supplies a whole
+	// object for it.
+	with := ReplacementValue{
+		Value: cty.ObjectVal(map[string]cty.Value{
+			"id": cty.ObjectVal(map[string]cty.Value{
+				"deep": cty.StringVal("too deep"),
+			}),
+		}),
+	}
+
+	containsDepthDiag := func(diags tfdiags.Diagnostics) bool {
+		for _, diag := range diags {
+			if strings.Contains(diag.Description().Detail, "nests more deeply") {
+				return true
+			}
+		}
+		return false
+	}
+
+	testRand = rand.New(rand.NewSource(0))
+	defer func() {
+		testRand = nil
+	}()
+
+	// By default we just get the generic conversion failure.
+	_, diags := ComputedValuesForDataSource(target, with, &computedBlock)
+	if containsDepthDiag(diags) {
+		t.Errorf("did not expect a depth diagnostic without the option")
+	}
+
+	generator := NewValueGenerator(GenerateOptions{
+		ValidateOverrideDepth: true,
+	})
+	_, diags = generator.ComputedValuesForDataSource(target, with, &computedBlock)
+	if !containsDepthDiag(diags) {
+		t.Errorf("expected a depth diagnostic, got: %v", diags.Err())
+	}
+}
+
+func TestValueGenerator_unknownPolicy(t *testing.T) {
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"id": {
+				Type:     cty.String,
+				Computed: true,
+			},
+			"arn": {
+				Type:     cty.String,
+				Computed: true,
+			},
+		},
+	}
+
+	target := cty.ObjectVal(map[string]cty.Value{
+		"id":  cty.UnknownVal(cty.String),
+		"arn": cty.UnknownVal(cty.String),
+	})
+
+	tcs := map[string]struct {
+		opts          GenerateOptions
+		expectKnown   map[string]bool
+		expectedError bool
+	}{
+		"default_generates": {
+			opts:        GenerateOptions{},
+			expectKnown: map[string]bool{"id": true, "arn": true},
+		},
+		"global_preserve": {
+			opts: GenerateOptions{
+				UnknownPolicy: UnknownPolicyPreserve,
+			},
+			expectKnown: map[string]bool{"id": false, "arn": false},
+		},
+		"global_error": {
+			opts: GenerateOptions{
+				UnknownPolicy: UnknownPolicyError,
+			},
+			expectKnown:   map[string]bool{"id": false, "arn": false},
+			expectedError: true,
+		},
+		"per_path_preserve": {
+			opts: GenerateOptions{
+				UnknownPolicyPerPath: map[string]UnknownPolicy{
+					"arn": UnknownPolicyPreserve,
+				},
+			},
+			expectKnown: map[string]bool{"id": true, "arn": false},
+		},
+		"per_path_generate_overrides_global": {
+			opts: GenerateOptions{
+				UnknownPolicy: UnknownPolicyPreserve,
+				UnknownPolicyPerPath: map[string]UnknownPolicy{
+					"id": UnknownPolicyGenerate,
+				},
+			},
+			expectKnown: map[string]bool{"id": true, "arn": false},
+		},
+		"per_path_error": {
+			opts: GenerateOptions{
+				UnknownPolicyPerPath: map[string]UnknownPolicy{
+					"arn": UnknownPolicyError,
+				},
+			},
+			expectKnown:   map[string]bool{"id": true, "arn": false},
+			expectedError: true,
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			testRand = rand.New(rand.NewSource(0))
+			defer func() {
+				testRand = nil
+			}()
+
+			generator := NewValueGenerator(tc.opts)
+			actual, diags := generator.ApplyComputedValuesForResource(target, ReplacementValue{}, schema)
+
+			if diags.HasErrors() != tc.expectedError {
+				t.Errorf("expected error: %t, got: %v", tc.expectedError, diags.Err())
+			}
+
+			for attr, known := range tc.expectKnown {
+				if actual.GetAttr(attr).IsKnown() != known {
+					t.Errorf("expected %s known to be %t, got %s", attr, known, actual.GetAttr(attr).GoString())
+				}
+			}
+		})
+	}
+}
+
+func TestValueGenerator_immutableAttributes(t *testing.T) {
+	// Simulate an update: the computed id is being regenerated, but a prior
+	// state exists and the caller declared id immutable.
+	target := cty.ObjectVal(map[string]cty.Value{
+		"id":    cty.NullVal(cty.String),
+		"value": cty.StringVal("updated"),
+	})
+
+	prior := cty.ObjectVal(map[string]cty.Value{
+		"id":    cty.StringVal("original"),
+		"value": cty.StringVal("created"),
+	})
+
+	testRand = rand.New(rand.NewSource(0))
+	defer func() {
+		testRand = nil
+	}()
+
+	generator := NewValueGenerator(GenerateOptions{
+		Prior:               prior,
+		ImmutableAttributes: []string{"id"},
+	})
+
+	actual, diags := generator.ApplyComputedValuesForResource(cty.ObjectVal(map[string]cty.Value{
+		"id":    cty.UnknownVal(cty.String),
+		"value": cty.StringVal("updated"),
+	}), ReplacementValue{}, &computedBlock)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+
+	if id := actual.GetAttr("id").AsString(); id != "original" {
+		t.Errorf("expected the immutable id to be preserved from the prior state, got %q", id)
+	}
+
+	// Without the immutability flag the id gets regenerated.
+	testRand = rand.New(rand.NewSource(0))
+	generator = NewValueGenerator(GenerateOptions{
+		Prior: prior,
+	})
+	actual, _ = generator.ComputedValuesForDataSource(target, ReplacementValue{}, &computedBlock)
+	if id := actual.GetAttr("id").AsString(); id == "original" {
+		t.Errorf("expected a freshly generated id without the immutability flag")
+	}
+
+	// An explicit override is the caller's choice and beats the prior value
+	// even for an immutable attribute.
+	testRand = rand.New(rand.NewSource(0))
+	generator = NewValueGenerator(GenerateOptions{
+		Prior:               prior,
+		ImmutableAttributes: []string{"id"},
+	})
+	actual, diags = generator.ApplyComputedValuesForResource(cty.ObjectVal(map[string]cty.Value{
+		"id":    cty.UnknownVal(cty.String),
+		"value": cty.StringVal("updated"),
+	}), ReplacementValue{
+		Value: cty.ObjectVal(map[string]cty.Value{
+			"id": cty.StringVal("explicit-override"),
+		}),
+	}, &computedBlock)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+	if id := actual.GetAttr("id").AsString(); id != "explicit-override" {
+		t.Errorf("expected the override to beat the prior value, got %q", id)
+	}
+}
+
+func TestValueGenerator_nullOverrideStillGenerates(t *testing.T) {
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"nested": {
+				NestedType: &configschema.Object{
+					Attributes: computedAttributes,
+					Nesting:    configschema.NestingSingle,
+				},
+			},
+		},
+	}
+
+	target := cty.ObjectVal(map[string]cty.Value{
+		"nested": cty.ObjectVal(map[string]cty.Value{
+			"id":    cty.NullVal(cty.String),
+			"value": cty.StringVal("Hello, world!"),
+		}),
+	})
+
+	// The override mentions the computed id, but only to leave it null. That
+	// shouldn't pin the output to null: generation fills anything the
+	// override didn't actually supply a value for.
+	with := ReplacementValue{
+		Value: cty.ObjectVal(map[string]cty.Value{
+			"nested": cty.ObjectVal(map[string]cty.Value{
+				"id": cty.NullVal(cty.String),
+			}),
+		}),
+	}
+
+	testRand = rand.New(rand.NewSource(0))
+	defer func() {
+		testRand = nil
+	}()
+
+	actual, diags := ComputedValuesForDataSource(target, with, schema)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+
+	if actual.GetAttr("nested").GetAttr("id").IsNull() {
+		t.Errorf("expected the computed id to be generated despite the null override")
+	}
+}
+
+func TestValueGenerator_reservedValues(t *testing.T) {
+	target := cty.ObjectVal(map[string]cty.Value{
+		"id":    cty.NullVal(cty.String),
+		"value": cty.StringVal("Hello, world!"),
+	})
+
+	// Under seed zero the first random draw is always "ssnk9qhr" and the
+	// second "amyllmyg", so reserving the first forces a redraw.
+	testRand = rand.New(rand.NewSource(0))
+	defer func() {
+		testRand = nil
+	}()
+
+	generator := NewValueGenerator(GenerateOptions{
+		ReservedValues: []string{"ssnk9qhr"},
+	})
+
+	actual, diags := generator.ComputedValuesForDataSource(target, ReplacementValue{}, &computedBlock)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+
+	if id := actual.GetAttr("id").AsString(); id != "amyllmyg" {
+		t.Errorf("expected the reserved draw to be skipped in favour of the next one, got %q", id)
+	}
+}
+
+func TestValueGenerator_templates(t *testing.T) {
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"id": {
+				Type:     cty.String,
+				Computed: true,
+			},
+			"arn": {
+				Type:     cty.String,
+				Computed: true,
+			},
+		},
+	}
+
+	target := cty.ObjectVal(map[string]cty.Value{
+		"id":  cty.NullVal(cty.String),
+		"arn": cty.NullVal(cty.String),
+	})
+
+	testRand = rand.New(rand.NewSource(0))
+	defer func() {
+		testRand = nil
+	}()
+
+	generator := NewValueGenerator(GenerateOptions{
+		ResourceType: "test_instance",
+		Templates: map[string]string{
+			"id":  "${type}-${random}",
+			"arn": "arn:mock:${type}",
+		},
+	})
+
+	actual, diags := generator.ComputedValuesForDataSource(target, ReplacementValue{}, schema)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+
+	if arn := actual.GetAttr("arn").AsString(); arn != "arn:mock:test_instance" {
+		t.Errorf("expected templated arn, got %q", arn)
+	}
+
+	id := actual.GetAttr("id").AsString()
+	if !strings.HasPrefix(id, "test_instance-") {
+		t.Errorf("expected id to start with the resource type, got %q", id)
+	}
+	if len(id) != len("test_instance-")+8 {
+		t.Errorf("expected id to end with one random draw, got %q", id)
+	}
+}
+
+func TestValueGenerator_protocolVersion(t *testing.T) {
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"id": {
+				Type:     cty.String,
+				Computed: true,
+			},
+			"value": {
+				Type: cty.String,
+			},
+		},
+	}
+
+	testRand = rand.New(rand.NewSource(0))
+	defer func() {
+		testRand = nil
+	}()
+
+	// The target carries a refined unknown for the non-computed attribute,
+	// and the generator would mark the computed one as sensitive.
+	target := cty.ObjectVal(map[string]cty.Value{
+		"id":    cty.NullVal(cty.String),
+		"value": cty.UnknownVal(cty.String).RefineNotNull(),
+	})
+
+	generator := NewValueGenerator(GenerateOptions{
+		SensitivePredicates: map[string]func(cty.Value) bool{
+			"id": func(cty.Value) bool { return true },
+		},
+		ProtocolVersion: 5,
+	})
+
+	actual, diags := generator.ComputedValuesForDataSource(target, ReplacementValue{}, schema)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+
+	if marks.Contains(actual, marks.Sensitive) {
+		t.Errorf("expected no marks for protocol version 5, got %s", actual.GoString())
+	}
+	if actual.GetAttr("value").Range().DefinitelyNotNull() {
+		t.Errorf("expected refinements to be stripped for protocol version 5")
+	}
+
+	// The current protocol keeps both.
+	testRand = rand.New(rand.NewSource(0))
+	generator = NewValueGenerator(GenerateOptions{
+		SensitivePredicates: map[string]func(cty.Value) bool{
+			"id": func(cty.Value) bool { return true },
+		},
+	})
+	actual, _ = generator.ComputedValuesForDataSource(target, ReplacementValue{}, schema)
+	if !actual.GetAttr("id").HasMark(marks.Sensitive) {
+		t.Errorf("expected the sensitive mark to survive on the current protocol")
+	}
+	if !actual.GetAttr("value").Range().DefinitelyNotNull() {
+		t.Errorf("expected the refinement to survive on the current protocol")
+	}
+}
+
+func TestValueGenerator_stripAllMarks(t *testing.T) {
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"id": {
+				Type:     cty.String,
+				Computed: true,
+			},
+		},
+	}
+
+	target := cty.ObjectVal(map[string]cty.Value{
+		"id": cty.NullVal(cty.String),
+	})
+
+	testRand = rand.New(rand.NewSource(0))
+	defer func() {
+		testRand = nil
+	}()
+
+	generator := NewValueGenerator(GenerateOptions{
+		SensitivePredicates: map[string]func(cty.Value) bool{
+			"id": func(cty.Value) bool { return true },
+		},
+		StripAllMarks: true,
+	})
+
+	actual, diags := generator.ComputedValuesForDataSource(target, ReplacementValue{}, schema)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+
+	if marks.Contains(actual, marks.Sensitive) {
+		t.Errorf("expected all marks to be stripped from the output, got %s", actual.GoString())
+	}
+	if actual.GetAttr("id").IsNull() {
+		t.Errorf("expected the computed attribute to still be generated")
+	}
+}
+
+func TestValueGenerator_overrideCoverage(t *testing.T) {
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"id": {
+				Type:     cty.String,
+				Computed: true,
+			},
+			"arn": {
+				Type:     cty.String,
+				Computed: true,
+			},
+		},
+	}
+
+	target := cty.ObjectVal(map[string]cty.Value{
+		"id":  cty.NullVal(cty.String),
+		"arn": cty.NullVal(cty.String),
+	})
+
+	with := ReplacementValue{
+		Value: cty.ObjectVal(map[string]cty.Value{
+			"id": cty.StringVal("myvalue"),
+		}),
+	}
+
+	testRand = rand.New(rand.NewSource(0))
+	defer func() {
+		testRand = nil
+	}()
+
+	// With RequireExplicitOverrides the uncovered attribute stays null, with
+	// no diagnostics.
+	generator := NewValueGenerator(GenerateOptions{
+		RequireExplicitOverrides: true,
+	})
+	actual, diags := generator.ComputedValuesForDataSource(target, with, schema)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+	if actual.GetAttr("id").AsString() != "myvalue" {
+		t.Errorf("expected the override to apply to id")
+	}
+	if !actual.GetAttr("arn").IsNull() {
+		t.Errorf("expected the uncovered arn to stay null, got %s", actual.GetAttr("arn").GoString())
+	}
+
+	// With OverridesMustBeComplete the uncovered attribute is an error which
+	// names the missing path.
+	generator = NewValueGenerator(GenerateOptions{
+		OverridesMustBeComplete: true,
+	})
+	_, diags = generator.ComputedValuesForDataSource(target, with, schema)
+	if !diags.HasErrors() {
+		t.Fatalf("expected an error for the missing override, got none")
+	}
+	if detail := diags[0].Description().Detail; !strings.Contains(detail, "arn") {
+		t.Errorf("expected the diagnostic to name the missing attribute, got: %s", detail)
+	}
+}
+
+func TestValueGenerator_groupNestedAttribute(t *testing.T) {
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"group": {
+				NestedType: &configschema.Object{
+					Attributes: computedAttributes,
+					Nesting:    configschema.NestingGroup,
+				},
+			},
+		},
+	}
+
+	// The group attribute is entirely absent from the target, but group
+	// semantics say it should be present as an object with null fields, and
+	// its computed fields should then be filled as normal.
+	target := cty.ObjectVal(map[string]cty.Value{
+		"group": cty.NullVal(cty.Object(map[string]cty.Type{
+			"id":    cty.String,
+			"value": cty.String,
+		})),
+	})
+
+	testRand = rand.New(rand.NewSource(0))
+	defer func() {
+		testRand = nil
+	}()
+
+	actual, diags := ComputedValuesForDataSource(target, ReplacementValue{}, schema)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+
+	group := actual.GetAttr("group")
+	if group.IsNull() {
+		t.Fatalf("expected the absent group to be materialised, but it is null")
+	}
+	if group.GetAttr("id").IsNull() {
+		t.Errorf("expected the computed id within the group to be generated")
+	}
+	if !group.GetAttr("value").IsNull() {
+		t.Errorf("expected the non-computed value within the group to stay null")
+	}
+}
+
+func TestValueGenerator_maxDepth(t *testing.T) {
+	// Build a schema and matching target value nested more deeply than the
+	// default depth limit, with a single computed attribute at the bottom.
+	attrs := map[string]*configschema.Attribute{
+		"id": {
+			Type:     cty.String,
+			Computed: true,
+		},
+	}
+	value := cty.ObjectVal(map[string]cty.Value{
+		"id": cty.NullVal(cty.String),
+	})
+	for i := 0; i < defaultMaxDepth+1; i++ {
+		attrs = map[string]*configschema.Attribute{
+			"nested": {
+				NestedType: &configschema.Object{
+					Attributes: attrs,
+					Nesting:    configschema.NestingSingle,
+				},
+			},
+		}
+		value = cty.ObjectVal(map[string]cty.Value{
+			"nested": value,
+		})
+	}
+	schema := &configschema.Block{Attributes: attrs}
+
+	testRand = rand.New(rand.NewSource(0))
+	defer func() {
+		testRand = nil
+	}()
+
+	// At the default depth limit, the deeply nested computed attribute should
+	// trigger an error.
+	_, diags := ComputedValuesForDataSource(value, ReplacementValue{}, schema)
+	if !diags.HasErrors() {
+		t.Errorf("expected an error at the default depth limit, got none")
+	}
+
+	// With the limit raised, the same schema should generate cleanly.
+	generator := NewValueGenerator(GenerateOptions{
+		MaxDepth: defaultMaxDepth * 2,
+	})
+	actual, diags := generator.ComputedValuesForDataSource(value, ReplacementValue{}, schema)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors with a raised depth limit: %s", diags.Err())
+	}
+
+	leaf := actual
+	for i := 0; i < defaultMaxDepth+1; i++ {
+		leaf = leaf.GetAttr("nested")
+	}
+	if leaf.GetAttr("id").IsNull() {
+		t.Errorf("expected the deeply nested computed attribute to be generated")
+	}
+}
+
+func TestValueGenerator_caseInsensitiveOverrideKeys(t *testing.T) {
+	target := cty.ObjectVal(map[string]cty.Value{
+		"id":    cty.NullVal(cty.String),
+		"value": cty.StringVal("Hello, world!"),
+	})
+
+	tcs := map[string]struct {
+		key             string
+		caseInsensitive bool
+		expectOverride  bool
+	}{
+		"exact_match_default": {
+			key:            "id",
+			expectOverride: true,
+		},
+		"case_mismatch_default": {
+			key:            "ID",
+			expectOverride: false,
+		},
+		"case_mismatch_insensitive": {
+			key:             "ID",
+			caseInsensitive: true,
+			expectOverride:  true,
+		},
+		"whitespace_mismatch_insensitive": {
+			key:             " id ",
+			caseInsensitive: true,
+			expectOverride:  true,
+		},
+		"unmatched_key_default": {
+			key:            "identifier",
+			expectOverride: false,
+		},
+		"unmatched_key_insensitive": {
+			key:             "identifier",
+			caseInsensitive: true,
+			expectOverride:  false,
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			testRand = rand.New(rand.NewSource(0))
+			defer func() {
+				testRand = nil
+			}()
+
+			with := ReplacementValue{
+				Value: cty.ObjectVal(map[string]cty.Value{
+					tc.key: cty.StringVal("myvalue"),
+				}),
+			}
+
+			generator := NewValueGenerator(GenerateOptions{
+				CaseInsensitiveOverrideKeys: tc.caseInsensitive,
+			})
+
+			actual, diags := generator.ComputedValuesForDataSource(target, with, &computedBlock)
+			if diags.HasErrors() {
+				t.Fatalf("unexpected errors: %s", diags.Err())
+			}
+
+			id := actual.GetAttr("id").AsString()
+			if tc.expectOverride {
+				if id != "myvalue" {
+					t.Errorf("expected the override to apply, but id was %q", id)
+				}
+			} else {
+				if id == "myvalue" {
+					t.Errorf("expected the override to be ignored, but it applied")
+				}
+			}
+		})
+	}
+}
+
+func TestValueGenerator_requireUniqueListElements(t *testing.T) {
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"id": {
+				Type:     cty.String,
+				Computed: true,
+			},
+			"zones": {
+				Type:     cty.List(cty.String),
+				Required: true,
+			},
+		},
+	}
+
+	tcs := map[string]struct {
+		zones       []cty.Value
+		marked      bool
+		expectError bool
+	}{
+		"unique": {
+			zones: []cty.Value{
+				cty.StringVal("us-east-1a"),
+				cty.StringVal("us-east-1b"),
+			},
+			expectError: false,
+		},
+		"duplicate": {
+			zones: []cty.Value{
+				cty.StringVal("us-east-1a"),
+				cty.StringVal("us-east-1a"),
+			},
+			expectError: true,
+		},
+		"duplicate_marked": {
+			// A sensitive mark on the list shouldn't stop the duplicates
+			// within it from being reported.
+			zones: []cty.Value{
+				cty.StringVal("us-east-1a"),
+				cty.StringVal("us-east-1a"),
+			},
+			marked:      true,
+			expectError: true,
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			zones := cty.ListVal(tc.zones)
+			if tc.marked {
+				zones = zones.Mark(marks.Sensitive)
+			}
+
+			target := cty.ObjectVal(map[string]cty.Value{
+				"id":    cty.NullVal(cty.String),
+				"zones": zones,
+			})
+
+			generator := NewValueGenerator(GenerateOptions{
+				RequireUniqueListElements: []string{"zones"},
+			})
+
+			_, diags := generator.ComputedValuesForDataSource(target, ReplacementValue{}, schema)
+			if tc.expectError {
+				if !diags.HasErrors() {
+					t.Fatalf("expected an error for duplicate elements, but got none")
+				}
+				if summary := diags[0].Description().Summary; summary != "Duplicate list elements" {
+					t.Errorf("unexpected summary: %s", summary)
+				}
+				if detail := diags[0].Description().Detail; !strings.Contains(detail, "of zones") {
+					t.Errorf("expected the detail to name the list, but got: %s", detail)
+				}
+			} else {
+				if diags.HasErrors() {
+					t.Fatalf("unexpected errors: %s", diags.Err())
+				}
+			}
+		})
+	}
+}
+
+func TestValueGenerator_typeTransforms(t *testing.T) {
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"id": {
+				Type:     cty.String,
+				Computed: true,
+			},
+			"count": {
+				Type:     cty.Number,
+				Computed: true,
+			},
+		},
+	}
+
+	target := cty.ObjectVal(map[string]cty.Value{
+		"id":    cty.NullVal(cty.String),
+		"count": cty.NullVal(cty.Number),
+	})
+
+	testRand = rand.New(rand.NewSource(0))
+	defer func() {
+		testRand = nil
+	}()
+
+	generator := NewValueGenerator(GenerateOptions{
+		TypeTransforms: []TypeTransform{
+			{
+				Type: cty.String,
+				Transform: func(value cty.Value) cty.Value {
+					return cty.StringVal(strings.ToUpper(value.AsString()))
+				},
+			},
+			{
+				Type: cty.Number,
+				Transform: func(value cty.Value) cty.Value {
+					return cty.NumberIntVal(42)
+				},
+			},
+		},
+	})
+
+	actual, diags := generator.ComputedValuesForDataSource(target, ReplacementValue{}, schema)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+
+	if id := actual.GetAttr("id").AsString(); id != strings.ToUpper(id) {
+		t.Errorf("expected the string transform to uppercase id, got %q", id)
+	}
+	if count := actual.GetAttr("count"); count.Equals(cty.NumberIntVal(42)).False() {
+		t.Errorf("expected the number transform to apply, got %s", count.GoString())
+	}
+}
+
+func TestValueGenerator_globallyUniqueStrings(t *testing.T) {
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"id": {
+				Type:     cty.String,
+				Computed: true,
+			},
+		},
+	}
+
+	target := cty.ObjectVal(map[string]cty.Value{
+		"id": cty.NullVal(cty.String),
+	})
+
+	defer func() {
+		testRand = nil
+	}()
+
+	generator := NewValueGenerator(GenerateOptions{
+		GloballyUniqueStrings: true,
+	})
+
+	// Reseeding the random source before every item means a generator with
+	// no dedup set would produce the same id each time, so this exercises
+	// the redraw path as well as the bookkeeping.
+	seen := make(map[string]bool)
+	for ix := 0; ix < 5; ix++ {
+		testRand = rand.New(rand.NewSource(0))
+
+		value, diags := generator.ComputedValuesForDataSource(target, ReplacementValue{}, schema)
+		if diags.HasErrors() {
+			t.Fatalf("unexpected errors: %s", diags.Err())
+		}
+
+		id := value.GetAttr("id").AsString()
+		if seen[id] {
+			t.Errorf("generated duplicate id %q on item %d", id, ix)
+		}
+		seen[id] = true
+	}
+}
+
+func TestValueGenerator_targetType(t *testing.T) {
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"id": {
+				Type:     cty.String,
+				Computed: true,
+			},
+		},
+	}
+
+	target := cty.ObjectVal(map[string]cty.Value{
+		"id": cty.NullVal(cty.String),
+	})
+
+	t.Run("widening", func(t *testing.T) {
+		testRand = rand.New(rand.NewSource(0))
+		defer func() {
+			testRand = nil
+		}()
+
+		generator := NewValueGenerator(GenerateOptions{
+			TargetType: cty.Map(cty.String),
+		})
+
+		value, diags := generator.ComputedValuesForDataSource(target, ReplacementValue{}, schema)
+		if diags.HasErrors() {
+			t.Fatalf("unexpected errors: %s", diags.Err())
+		}
+
+		if !value.Type().Equals(cty.Map(cty.String)) {
+			t.Errorf("expected the result to be converted to %s, got %s", cty.Map(cty.String).FriendlyName(), value.Type().FriendlyName())
+		}
+		if value.Index(cty.StringVal("id")).IsNull() {
+			t.Errorf("expected the converted map to retain the generated id")
+		}
+	})
+
+	t.Run("failing", func(t *testing.T) {
+		testRand = rand.New(rand.NewSource(0))
+		defer func() {
+			testRand = nil
+		}()
+
+		generator := NewValueGenerator(GenerateOptions{
+			TargetType: cty.Map(cty.Bool),
+		})
+
+		_, diags := generator.ComputedValuesForDataSource(target, ReplacementValue{}, schema)
+		if !diags.HasErrors() {
+			t.Fatalf("expected a conversion error, but got none")
+		}
+		if summary := diags[0].Description().Summary; summary != "Failed to convert generated value" {
+			t.Errorf("unexpected summary: %s", summary)
+		}
+	})
+}
+
+func TestValueGenerator_shallow(t *testing.T) {
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"id": {
+				Type:     cty.String,
+				Computed: true,
+			},
+		},
+		BlockTypes: map[string]*configschema.NestedBlock{
+			"block": {
+				Block:   computedBlock,
+				Nesting: configschema.NestingList,
+			},
+		},
+	}
+
+	target := cty.ObjectVal(map[string]cty.Value{
+		"id": cty.NullVal(cty.String),
+		"block": cty.ListVal([]cty.Value{
+			cty.ObjectVal(map[string]cty.Value{
+				"id":    cty.NullVal(cty.String),
+				"value": cty.StringVal("one"),
+			}),
+		}),
+	})
+
+	testRand = rand.New(rand.NewSource(0))
+	defer func() {
+		testRand = nil
+	}()
+
+	generator := NewValueGenerator(GenerateOptions{
+		Shallow: true,
+	})
+
+	value, diags := generator.ComputedValuesForDataSource(target, ReplacementValue{}, schema)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+
+	if value.GetAttr("id").IsNull() {
+		t.Errorf("expected the top-level id to be generated in shallow mode")
+	}
+	if nested := value.GetAttr("block").Index(cty.NumberIntVal(0)).GetAttr("id"); !nested.IsNull() {
+		t.Errorf("expected the nested id to stay null in shallow mode, got %s", nested.GoString())
+	}
+
+	// At apply time the skipped nested value arrives unknown, and must come
+	// out null rather than leaking the unknown into the apply result.
+	value, diags = generator.ApplyComputedValuesForResource(cty.ObjectVal(map[string]cty.Value{
+		"id": cty.UnknownVal(cty.String),
+		"block": cty.ListVal([]cty.Value{
+			cty.ObjectVal(map[string]cty.Value{
+				"id":    cty.UnknownVal(cty.String),
+				"value": cty.StringVal("one"),
+			}),
+		}),
+	}), ReplacementValue{}, schema)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+	if id := value.GetAttr("id"); id.IsNull() || !id.IsKnown() {
+		t.Errorf("expected the top-level id to be generated at apply, got %s", id.GoString())
+	}
+	if nested := value.GetAttr("block").Index(cty.NumberIntVal(0)).GetAttr("id"); !nested.IsNull() {
+		t.Errorf("expected the skipped nested id to be null at apply, got %s", nested.GoString())
+	}
+}
+
+func TestValueGenerator_numberSetLength(t *testing.T) {
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"ports": {
+				Type:     cty.Set(cty.Number),
+				Computed: true,
+			},
+		},
+	}
+
+	target := cty.ObjectVal(map[string]cty.Value{
+		"ports": cty.NullVal(cty.Set(cty.Number)),
+	})
+
+	testRand = rand.New(rand.NewSource(0))
+	defer func() {
+		testRand = nil
+	}()
+
+	generator := NewValueGenerator(GenerateOptions{
+		NumberSetLength: 5,
+	})
+
+	value, diags := generator.ComputedValuesForDataSource(target, ReplacementValue{}, schema)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+
+	ports := value.GetAttr("ports")
+	if length := ports.LengthInt(); length != 5 {
+		t.Errorf("expected 5 distinct numbers in the set, got %d", length)
+	}
+
+	// And the default behaviour is still an empty set.
+	testRand = rand.New(rand.NewSource(0))
+	value, diags = ComputedValuesForDataSource(target, ReplacementValue{}, schema)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+	if length := value.GetAttr("ports").LengthInt(); length != 0 {
+		t.Errorf("expected an empty set by default, got %d elements", length)
+	}
+}
+
+func TestValueGenerator_phase(t *testing.T) {
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"id": {
+				Type:     cty.String,
+				Computed: true,
+			},
+			"name": {
+				Type:     cty.String,
+				Optional: true,
+				Computed: true,
+			},
+		},
+	}
+
+	target := cty.ObjectVal(map[string]cty.Value{
+		"id":   cty.NullVal(cty.String),
+		"name": cty.NullVal(cty.String),
+	})
+
+	tcs := map[string]struct {
+		phase             Phase
+		expectNameUnknown bool
+	}{
+		"plan": {
+			phase:             PhasePlan,
+			expectNameUnknown: true,
+		},
+		"apply": {
+			phase:             PhaseApply,
+			expectNameUnknown: false,
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			testRand = rand.New(rand.NewSource(0))
+			defer func() {
+				testRand = nil
+			}()
+
+			generator := NewValueGenerator(GenerateOptions{
+				Phase: tc.phase,
+			})
+
+			value, diags := generator.ComputedValuesForDataSource(target, ReplacementValue{}, schema)
+			if diags.HasErrors() {
+				t.Fatalf("unexpected errors: %s", diags.Err())
+			}
+
+			// Pure computed attributes are always generated, regardless of
+			// phase.
+			if !value.GetAttr("id").IsKnown() || value.GetAttr("id").IsNull() {
+				t.Errorf("expected id to be generated in phase %v", tc.phase)
+			}
+
+			if actual := !value.GetAttr("name").IsKnown(); actual != tc.expectNameUnknown {
+				t.Errorf("expected name unknown to be %t in phase %v, got %t", tc.expectNameUnknown, tc.phase, actual)
+			}
+		})
+	}
+}
+
+func TestValueGenerator_priorOptionalComputed(t *testing.T) {
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"id": {
+				Type:     cty.String,
+				Computed: true,
+			},
+			"name": {
+				Type:     cty.String,
+				Optional: true,
+				Computed: true,
+			},
+		},
+	}
+
+	target := cty.ObjectVal(map[string]cty.Value{
+		"id":   cty.NullVal(cty.String),
+		"name": cty.NullVal(cty.String),
+	})
+
+	prior := cty.ObjectVal(map[string]cty.Value{
+		"id":   cty.StringVal("prior-id"),
+		"name": cty.StringVal("prior-name"),
+	})
+
+	testRand = rand.New(rand.NewSource(0))
+	defer func() {
+		testRand = nil
+	}()
+
+	generator := NewValueGenerator(GenerateOptions{
+		Prior: prior,
+	})
+
+	value, diags := generator.ComputedValuesForDataSource(target, ReplacementValue{}, schema)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+
+	// The optional computed name was null in the configuration, so the prior
+	// value wins over regeneration to avoid a spurious diff.
+	if name := value.GetAttr("name").AsString(); name != "prior-name" {
+		t.Errorf("expected the prior name to be reused, got %q", name)
+	}
+
+	// The pure computed id wasn't flagged immutable, so it's still generated
+	// fresh.
+	if id := value.GetAttr("id").AsString(); id == "prior-id" {
+		t.Errorf("did not expect the prior id to be reused")
+	}
+}
+
+func TestValueGenerator_maxSetElements(t *testing.T) {
+	schema := &configschema.Block{
+		BlockTypes: map[string]*configschema.NestedBlock{
+			"block": {
+				Block:   computedBlock,
+				Nesting: configschema.NestingSet,
+			},
+		},
+	}
+
+	element := func(value string) cty.Value {
+		return cty.ObjectVal(map[string]cty.Value{
+			"id":    cty.NullVal(cty.String),
+			"value": cty.StringVal(value),
+		})
+	}
+
+	target := cty.ObjectVal(map[string]cty.Value{
+		"block": cty.SetVal([]cty.Value{
+			element("one"),
+			element("two"),
+			element("three"),
+		}),
+	})
+
+	testRand = rand.New(rand.NewSource(0))
+	defer func() {
+		testRand = nil
+	}()
+
+	generator := NewValueGenerator(GenerateOptions{
+		MaxSetElements: 2,
+	})
+
+	value, diags := generator.ComputedValuesForDataSource(target, ReplacementValue{}, schema)
+	if !diags.HasErrors() {
+		t.Fatalf("expected an error for the oversized set, but got none")
+	}
+	if len(diags) != 1 {
+		t.Fatalf("expected exactly one diagnostic for the whole set, got %d", len(diags))
+	}
+	if summary := diags[0].Description().Summary; summary != "Set too large to mock" {
+		t.Errorf("unexpected summary: %s", summary)
+	}
+
+	// The set itself is left exactly as it was.
+	if !value.GetAttr("block").RawEquals(target.GetAttr("block")) {
+		t.Errorf("expected the oversized set to be untouched, got %s", value.GetAttr("block").GoString())
+	}
+
+	// A generator with a high enough cap still fills the elements in.
+	testRand = rand.New(rand.NewSource(0))
+	generator = NewValueGenerator(GenerateOptions{
+		MaxSetElements: 3,
+	})
+	value, diags = generator.ComputedValuesForDataSource(target, ReplacementValue{}, schema)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+	for it := value.GetAttr("block").ElementIterator(); it.Next(); {
+		_, element := it.Element()
+		if element.GetAttr("id").IsNull() {
+			t.Errorf("expected every element id to be generated under the cap")
+		}
+	}
+}
+
+func TestValueGenerator_valuePools(t *testing.T) {
+	schema := &configschema.Block{
+		BlockTypes: map[string]*configschema.NestedBlock{
+			"block": {
+				Block:   computedBlock,
+				Nesting: configschema.NestingList,
+			},
+		},
+	}
+
+	element := func(value string) cty.Value {
+		return cty.ObjectVal(map[string]cty.Value{
+			"id":    cty.NullVal(cty.String),
+			"value": cty.StringVal(value),
+		})
+	}
+
+	target := cty.ObjectVal(map[string]cty.Value{
+		"block": cty.ListVal([]cty.Value{
+			element("one"),
+			element("two"),
+			element("three"),
+		}),
+	})
+
+	testRand = rand.New(rand.NewSource(0))
+	defer func() {
+		testRand = nil
+	}()
+
+	generator := NewValueGenerator(GenerateOptions{
+		ValuePools: map[string][]cty.Value{
+			"block.id": {
+				cty.StringVal("first"),
+				cty.StringVal("second"),
+			},
+		},
+	})
+
+	value, diags := generator.ComputedValuesForDataSource(target, ReplacementValue{}, schema)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+
+	// The three list elements cycle through the two pool values in order.
+	expected := []string{"first", "second", "first"}
+	for ix, want := range expected {
+		if id := value.GetAttr("block").Index(cty.NumberIntVal(int64(ix))).GetAttr("id").AsString(); id != want {
+			t.Errorf("expected element %d to take pool value %q, got %q", ix, want, id)
+		}
+	}
+}
+
+func TestValueGenerator_warnOnConfigCollisions(t *testing.T) {
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"id": {
+				Type:     cty.String,
+				Computed: true,
+			},
+			"name": {
+				Type:     cty.String,
+				Optional: true,
+			},
+		},
+	}
+
+	// Seed zero generates "ssnk9qhr" for the id, so a config value of the
+	// same string forces a collision.
+	target := cty.ObjectVal(map[string]cty.Value{
+		"id":   cty.NullVal(cty.String),
+		"name": cty.StringVal("ssnk9qhr"),
+	})
+
+	testRand = rand.New(rand.NewSource(0))
+	defer func() {
+		testRand = nil
+	}()
+
+	generator := NewValueGenerator(GenerateOptions{
+		WarnOnConfigCollisions: true,
+	})
+
+	_, diags := generator.ComputedValuesForDataSource(target, ReplacementValue{}, schema)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+	if len(diags) != 1 {
+		t.Fatalf("expected exactly one warning, got %d diagnostics", len(diags))
+	}
+	if severity := diags[0].Severity(); severity != tfdiags.Warning {
+		t.Errorf("expected a warning, got %v", severity)
+	}
+	if summary := diags[0].Description().Summary; summary != "Generated value matches configuration" {
+		t.Errorf("unexpected summary: %s", summary)
+	}
+
+	// Without the option the collision passes silently.
+	testRand = rand.New(rand.NewSource(0))
+	_, diags = ComputedValuesForDataSource(target, ReplacementValue{}, schema)
+	if len(diags) != 0 {
+		t.Errorf("expected no diagnostics by default, got %d", len(diags))
+	}
+}
+
+// fixedClock implements Clock with a constant time.
+type fixedClock struct {
+	now time.Time
+}
+
+func (clock fixedClock) Now() time.Time {
+	return clock.now
+}
+
+func TestValueGenerator_clock(t *testing.T) {
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"created_at": {
+				Type:     cty.String,
+				Computed: true,
+			},
+			"expiry_time": {
+				Type:     cty.String,
+				Computed: true,
+			},
+		},
+	}
+
+	target := cty.ObjectVal(map[string]cty.Value{
+		"created_at":  cty.NullVal(cty.String),
+		"expiry_time": cty.NullVal(cty.String),
+	})
+
+	testRand = rand.New(rand.NewSource(0))
+	defer func() {
+		testRand = nil
+	}()
+
+	now := time.Date(2024, time.March, 1, 12, 30, 0, 0, time.UTC)
+	generator := NewValueGenerator(GenerateOptions{
+		Clock: fixedClock{now: now},
+	})
+
+	value, diags := generator.ComputedValuesForDataSource(target, ReplacementValue{}, schema)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+
+	for _, attr := range []string{"created_at", "expiry_time"} {
+		if actual := value.GetAttr(attr).AsString(); actual != "2024-03-01T12:30:00Z" {
+			t.Errorf("expected %s to come from the fake clock, got %q", attr, actual)
+		}
+	}
+}
+
+func TestValueGenerator_tupleComputedPositions(t *testing.T) {
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"pair": {
+				Type:     cty.Tuple([]cty.Type{cty.String, cty.String}),
+				Computed: true,
+			},
+		},
+	}
+
+	target := cty.ObjectVal(map[string]cty.Value{
+		"pair": cty.TupleVal([]cty.Value{
+			cty.NullVal(cty.String),
+			cty.StringVal("keep"),
+		}),
+	})
+
+	testRand = rand.New(rand.NewSource(0))
+	defer func() {
+		testRand = nil
+	}()
+
+	generator := NewValueGenerator(GenerateOptions{
+		TupleComputedPositions: map[string][]int{
+			"pair": {0},
+		},
+	})
+
+	value, diags := generator.ComputedValuesForDataSource(target, ReplacementValue{}, schema)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+
+	pair := value.GetAttr("pair")
+	if first := pair.Index(cty.NumberIntVal(0)); first.IsNull() {
+		t.Errorf("expected position 0 to be generated, but it is still null")
+	}
+	if second := pair.Index(cty.NumberIntVal(1)); second.AsString() != "keep" {
+		t.Errorf("expected position 1 to be preserved, got %s", second.GoString())
+	}
+
+	// Without the hints, tuple elements are left entirely alone.
+	testRand = rand.New(rand.NewSource(0))
+	value, diags = ComputedValuesForDataSource(target, ReplacementValue{}, schema)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+	if !value.GetAttr("pair").Index(cty.NumberIntVal(0)).IsNull() {
+		t.Errorf("expected position 0 to stay null without hints")
+	}
+}
+
+func TestValueGenerator_mapBlockEntries(t *testing.T) {
+	schema := &configschema.Block{
+		BlockTypes: map[string]*configschema.NestedBlock{
+			"settings": {
+				Block:   computedBlock,
+				Nesting: configschema.NestingMap,
+			},
+		},
+	}
+
+	elementType := computedBlock.ImpliedType()
+	target := cty.ObjectVal(map[string]cty.Value{
+		"settings": cty.MapValEmpty(elementType),
+	})
+
+	testRand = rand.New(rand.NewSource(0))
+	defer func() {
+		testRand = nil
+	}()
+
+	generator := NewValueGenerator(GenerateOptions{
+		MapBlockEntries: map[string]int{
+			"settings": 3,
+		},
+	})
+
+	value, diags := generator.ComputedValuesForDataSource(target, ReplacementValue{}, schema)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+
+	settings := value.GetAttr("settings")
+	if length := settings.LengthInt(); length != 3 {
+		t.Fatalf("expected 3 generated entries, got %d", length)
+	}
+
+	seen := make(map[string]bool)
+	for it := settings.ElementIterator(); it.Next(); {
+		key, entry := it.Element()
+		if seen[key.AsString()] {
+			t.Errorf("generated duplicate key %q", key.AsString())
+		}
+		seen[key.AsString()] = true
+
+		if entry.GetAttr("id").IsNull() {
+			t.Errorf("expected entry %q to have a generated id", key.AsString())
+		}
+	}
+
+	// Without the option the empty block stays empty.
+	testRand = rand.New(rand.NewSource(0))
+	value, diags = ComputedValuesForDataSource(target, ReplacementValue{}, schema)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+	if length := value.GetAttr("settings").LengthInt(); length != 0 {
+		t.Errorf("expected the block to stay empty by default, got %d entries", length)
+	}
+}
+
+// zeroSource implements RandSource with a source that always returns zero,
+// so every generated string comes out as "aaaaaaaa".
+type zeroSource struct{}
+
+func (zeroSource) Intn(n int) int {
+	return 0
+}
+
+func TestValueGenerator_randSource(t *testing.T) {
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"id": {
+				Type:     cty.String,
+				Computed: true,
+			},
+		},
+	}
+
+	target := cty.ObjectVal(map[string]cty.Value{
+		"id": cty.NullVal(cty.String),
+	})
+
+	generator := NewValueGenerator(GenerateOptions{
+		Rand: zeroSource{},
+	})
+
+	value, diags := generator.ComputedValuesForDataSource(target, ReplacementValue{}, schema)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+
+	// The zero source always picks the first character of the alphabet.
+	if id := value.GetAttr("id").AsString(); id != "aaaaaaaa" {
+		t.Errorf("expected the custom source to drive generation, got %q", id)
+	}
+}
+
+func TestValueGenerator_links(t *testing.T) {
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"id": {
+				Type:     cty.String,
+				Computed: true,
+			},
+			"secondary_id": {
+				Type:     cty.String,
+				Computed: true,
+			},
+		},
+	}
+
+	target := cty.ObjectVal(map[string]cty.Value{
+		"id":           cty.NullVal(cty.String),
+		"secondary_id": cty.NullVal(cty.String),
+	})
+
+	testRand = rand.New(rand.NewSource(0))
+	defer func() {
+		testRand = nil
+	}()
+
+	generator := NewValueGenerator(GenerateOptions{
+		Links: map[string]string{
+			"secondary_id": "id",
+		},
+	})
+
+	value, diags := generator.ComputedValuesForDataSource(target, ReplacementValue{}, schema)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+
+	id := value.GetAttr("id").AsString()
+	if secondary := value.GetAttr("secondary_id").AsString(); secondary != id {
+		t.Errorf("expected secondary_id to equal id %q, got %q", id, secondary)
+	}
+
+	// A link to a path that doesn't exist is an error.
+	testRand = rand.New(rand.NewSource(0))
+	generator = NewValueGenerator(GenerateOptions{
+		Links: map[string]string{
+			"secondary_id": "nonexistent",
+		},
+	})
+	_, diags = generator.ComputedValuesForDataSource(target, ReplacementValue{}, schema)
+	if !diags.HasErrors() {
+		t.Errorf("expected an error for a dangling link, but got none")
+	}
+}
+
+func TestValueGenerator_stringLengths(t *testing.T) {
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"id": {
+				Type:     cty.String,
+				Computed: true,
+			},
+		},
+	}
+
+	target := cty.ObjectVal(map[string]cty.Value{
+		"id": cty.NullVal(cty.String),
+	})
+
+	testRand = rand.New(rand.NewSource(0))
+	defer func() {
+		testRand = nil
+	}()
+
+	generator := NewValueGenerator(GenerateOptions{
+		StringLengths: map[string]int{
+			"id": 16,
+		},
+	})
+
+	value, diags := generator.ComputedValuesForDataSource(target, ReplacementValue{}, schema)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+	if id := value.GetAttr("id").AsString(); len(id) != 16 {
+		t.Errorf("expected a 16 byte string, got %q", id)
+	}
+
+	// A length over the hard cap is refused with a diagnostic, and the
+	// value falls back to the default length.
+	testRand = rand.New(rand.NewSource(0))
+	generator = NewValueGenerator(GenerateOptions{
+		StringLengths: map[string]int{
+			"id": maxGeneratedStringLength + 1,
+		},
+	})
+
+	value, diags = generator.ComputedValuesForDataSource(target, ReplacementValue{}, schema)
+	if !diags.HasErrors() {
+		t.Fatalf("expected an error for an excessive length, but got none")
+	}
+	if summary := diags[0].Description().Summary; summary != "Requested mock string too large" {
+		t.Errorf("unexpected summary: %s", summary)
+	}
+	if id := value.GetAttr("id").AsString(); len(id) != 8 {
+		t.Errorf("expected the default length fallback, got %d bytes", len(id))
+	}
+}
+
+func TestValueGenerator_coerceJSONOverrides(t *testing.T) {
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"zones": {
+				Type:     cty.List(cty.String),
+				Computed: true,
+			},
+		},
+	}
+
+	target := cty.ObjectVal(map[string]cty.Value{
+		"zones": cty.NullVal(cty.List(cty.String)),
+	})
+
+	tcs := map[string]struct {
+		override    string
+		expectError bool
+	}{
+		"valid_json": {
+			override: `["us-east-1a", "us-east-1b"]`,
+		},
+		"malformed_json": {
+			override:    `["us-east-1a",`,
+			expectError: true,
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			testRand = rand.New(rand.NewSource(0))
+			defer func() {
+				testRand = nil
+			}()
+
+			with := ReplacementValue{
+				Value: cty.ObjectVal(map[string]cty.Value{
+					"zones": cty.StringVal(tc.override),
+				}),
+			}
+
+			generator := NewValueGenerator(GenerateOptions{
+				CoerceJSONOverrides: true,
+			})
+
+			value, diags := generator.ComputedValuesForDataSource(target, with, schema)
+			if tc.expectError {
+				if !diags.HasErrors() {
+					t.Fatalf("expected a type mismatch error, but got none")
+				}
+				return
+			}
+
+			if diags.HasErrors() {
+				t.Fatalf("unexpected errors: %s", diags.Err())
+			}
+
+			expected := cty.ListVal([]cty.Value{
+				cty.StringVal("us-east-1a"),
+				cty.StringVal("us-east-1b"),
+			})
+			if zones := value.GetAttr("zones"); zones.Equals(expected).False() {
+				t.Errorf("expected the JSON override to be coerced, got %s", zones.GoString())
+			}
+		})
+	}
+
+	// Without the option even valid JSON is a type mismatch.
+	testRand = rand.New(rand.NewSource(0))
+	defer func() {
+		testRand = nil
+	}()
+	with := ReplacementValue{
+		Value: cty.ObjectVal(map[string]cty.Value{
+			"zones": cty.StringVal(`["us-east-1a"]`),
+		}),
+	}
+	_, diags := ComputedValuesForDataSource(target, with, schema)
+	if !diags.HasErrors() {
+		t.Errorf("expected a type mismatch without the option, but got none")
+	}
+}
+
+func TestValueGenerator_refinedInnerUnknowns(t *testing.T) {
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"metadata": {
+				Type: cty.Object(map[string]cty.Type{
+					"arn":  cty.String,
+					"name": cty.String,
+				}),
+				Computed: true,
+			},
+		},
+	}
+
+	// The provider planned the object as known, but refined the inner arn
+	// rather than deciding it, so the apply step has to fill it in without
+	// contradicting the refinement.
+	target := cty.ObjectVal(map[string]cty.Value{
+		"metadata": cty.ObjectVal(map[string]cty.Value{
+			"arn":  cty.UnknownVal(cty.String).Refine().NotNull().StringPrefixFull("arn:aws:").NewValue(),
+			"name": cty.StringVal("existing"),
+		}),
+	})
+
+	testRand = rand.New(rand.NewSource(0))
+	defer func() {
+		testRand = nil
+	}()
+
+	value, diags := ApplyComputedValuesForResource(target, ReplacementValue{}, schema)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+
+	arn := value.GetAttr("metadata").GetAttr("arn")
+	if !arn.IsKnown() || arn.IsNull() {
+		t.Fatalf("expected the refined unknown to be filled, got %s", arn.GoString())
+	}
+	if !strings.HasPrefix(arn.AsString(), "arn:aws:") {
+		t.Errorf("expected the generated value to honour the prefix refinement, got %q", arn.AsString())
+	}
+
+	// The known sibling inside the object is preserved.
+	if name := value.GetAttr("metadata").GetAttr("name").AsString(); name != "existing" {
+		t.Errorf("expected the known inner value to be preserved, got %q", name)
+	}
+}
+
+func TestValueGenerator_duplicateSetOverride(t *testing.T) {
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"zones": {
+				Type:     cty.Set(cty.String),
+				Computed: true,
+			},
+		},
+	}
+
+	target := cty.ObjectVal(map[string]cty.Value{
+		"zones": cty.NullVal(cty.Set(cty.String)),
+	})
+
+	tcs := map[string]struct {
+		override    cty.Value
+		expectError bool
+	}{
+		"distinct": {
+			override: cty.TupleVal([]cty.Value{
+				cty.StringVal("us-east-1a"),
+				cty.StringVal("us-east-1b"),
+			}),
+		},
+		"duplicated": {
+			override: cty.TupleVal([]cty.Value{
+				cty.StringVal("us-east-1a"),
+				cty.StringVal("us-east-1a"),
+			}),
+			expectError: true,
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			with := ReplacementValue{
+				Value: cty.ObjectVal(map[string]cty.Value{
+					"zones": tc.override,
+				}),
+			}
+
+			generator := NewValueGenerator(GenerateOptions{})
+
+			_, diags := generator.ComputedValuesForDataSource(target, with, schema)
+			if tc.expectError {
+				if !diags.HasErrors() {
+					t.Fatal("expected a duplicate element error, but got none")
+				}
+				if summary := diags[0].Description().Summary; summary != "Duplicate set elements in override" {
+					t.Errorf("wrong summary: %s", summary)
+				}
+				return
+			}
+
+			if diags.HasErrors() {
+				t.Fatalf("unexpected errors: %s", diags.Err())
+			}
+		})
+	}
+}
+
+func TestValueGenerator_exactlyOneOf(t *testing.T) {
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"ipv4_address": {
+				Type:     cty.String,
+				Optional: true,
+				Computed: true,
+			},
+			"ipv6_address": {
+				Type:     cty.String,
+				Optional: true,
+				Computed: true,
+			},
+		},
+	}
+
+	opts := GenerateOptions{
+		ExactlyOneOf: [][]string{
+			{"ipv4_address", "ipv6_address"},
+		},
+	}
+
+	t.Run("neither_set", func(t *testing.T) {
+		target := cty.ObjectVal(map[string]cty.Value{
+			"ipv4_address": cty.NullVal(cty.String),
+			"ipv6_address": cty.NullVal(cty.String),
+		})
+
+		value, diags := NewValueGenerator(opts).ComputedValuesForDataSource(target, ReplacementValue{}, schema)
+		if diags.HasErrors() {
+			t.Fatalf("unexpected errors: %s", diags.Err())
+		}
+
+		// The first member of the group is the one that gets a value.
+		if value.GetAttr("ipv4_address").IsNull() {
+			t.Error("expected the first group member to receive a value")
+		}
+		if !value.GetAttr("ipv6_address").IsNull() {
+			t.Error("expected the second group member to stay null")
+		}
+	})
+
+	t.Run("second_already_set", func(t *testing.T) {
+		target := cty.ObjectVal(map[string]cty.Value{
+			"ipv4_address": cty.NullVal(cty.String),
+			"ipv6_address": cty.StringVal("::1"),
+		})
+
+		value, diags := NewValueGenerator(opts).ComputedValuesForDataSource(target, ReplacementValue{}, schema)
+		if diags.HasErrors() {
+			t.Fatalf("unexpected errors: %s", diags.Err())
+		}
+
+		// The target already satisfies the group, so nothing else is filled.
+		if !value.GetAttr("ipv4_address").IsNull() {
+			t.Error("expected the unset group member to stay null")
+		}
+		if got := value.GetAttr("ipv6_address").AsString(); got != "::1" {
+			t.Errorf("expected the set group member to be preserved, got %q", got)
+		}
+	})
+
+	t.Run("apply", func(t *testing.T) {
+		// At apply time both members arrive unknown. The skipped member must
+		// come out null rather than unknown: apply results may not contain
+		// unknown values.
+		target := cty.ObjectVal(map[string]cty.Value{
+			"ipv4_address": cty.UnknownVal(cty.String),
+			"ipv6_address": cty.UnknownVal(cty.String),
+		})
+
+		value, diags := NewValueGenerator(opts).ApplyComputedValuesForResource(target, ReplacementValue{}, schema)
+		if diags.HasErrors() {
+			t.Fatalf("unexpected errors: %s", diags.Err())
+		}
+
+		if value.GetAttr("ipv4_address").IsNull() || !value.GetAttr("ipv4_address").IsKnown() {
+			t.Error("expected the first group member to receive a value")
+		}
+		if skipped := value.GetAttr("ipv6_address"); !skipped.IsNull() {
+			t.Errorf("expected the skipped group member to be null, got %s", skipped.GoString())
+		}
+	})
+}
+
+func TestValueGenerator_instanceKey(t *testing.T) {
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"id": {
+				Type:     cty.String,
+				Computed: true,
+			},
+		},
+	}
+
+	target := cty.ObjectVal(map[string]cty.Value{
+		"id": cty.NullVal(cty.String),
+	})
+
+	// An override of the wrong type gives us a diagnostic to inspect.
+	with := ReplacementValue{
+		Value: cty.ObjectVal(map[string]cty.Value{
+			"id": cty.ListVal([]cty.Value{cty.StringVal("nope")}),
+		}),
+	}
+
+	generator := NewValueGenerator(GenerateOptions{
+		ResourceType: "data.foo.bar",
+		InstanceKey:  addrs.IntKey(2),
+	})
+
+	_, diags := generator.ComputedValuesForDataSource(target, with, schema)
+	if !diags.HasErrors() {
+		t.Fatal("expected a replacement error, but got none")
+	}
+
+	detail := diags[0].Description().Detail
+	if !strings.Contains(detail, "data.foo.bar[2]") {
+		t.Errorf("expected the diagnostic to name the instance, got: %s", detail)
+	}
+
+	// The attribute path and cause classification survive the annotation.
+	// The cause wrapper has to come off before the path is visible.
+	if path := tfdiags.GetAttribute(tfdiags.UndoOverride(diags[0])); len(path) == 0 {
+		t.Error("expected the annotated diagnostic to keep its attribute path")
+	}
+	if cause := DiagnosticCauseOf(diags[0]); cause != CauseOverride {
+		t.Errorf("expected the annotated diagnostic to keep its cause, got %v", cause)
+	}
+}
+
+func TestValueGenerator_echoes(t *testing.T) {
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"name": {
+				Type:     cty.String,
+				Optional: true,
+			},
+			"matched_name": {
+				Type:     cty.String,
+				Computed: true,
+			},
+		},
+	}
+
+	target := cty.ObjectVal(map[string]cty.Value{
+		"name":         cty.StringVal("web"),
+		"matched_name": cty.NullVal(cty.String),
+	})
+
+	t.Run("echoed", func(t *testing.T) {
+		generator := NewValueGenerator(GenerateOptions{
+			Echoes: map[string]string{
+				"matched_name": "name",
+			},
+		})
+
+		value, diags := generator.ComputedValuesForDataSource(target, ReplacementValue{}, schema)
+		if diags.HasErrors() {
+			t.Fatalf("unexpected errors: %s", diags.Err())
+		}
+
+		if matched := value.GetAttr("matched_name").AsString(); matched != "web" {
+			t.Errorf("expected matched_name to echo the name input, got %q", matched)
+		}
+	})
+
+	t.Run("dangling", func(t *testing.T) {
+		generator := NewValueGenerator(GenerateOptions{
+			Echoes: map[string]string{
+				"matched_name": "missing",
+			},
+		})
+
+		_, diags := generator.ComputedValuesForDataSource(target, ReplacementValue{}, schema)
+		if !diags.HasErrors() {
+			t.Fatal("expected an error for the dangling echo, but got none")
+		}
+		if summary := diags[0].Description().Summary; summary != "Failed to echo attribute" {
+			t.Errorf("wrong summary: %s", summary)
+		}
+	})
+}
+
+func TestValueGenerator_alsoConformsTo(t *testing.T) {
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"id": {
+				Type:     cty.String,
+				Computed: true,
+			},
+		},
+	}
+
+	target := cty.ObjectVal(map[string]cty.Value{
+		"id": cty.NullVal(cty.String),
+	})
+
+	tcs := map[string]struct {
+		second      *configschema.Block
+		expectError bool
+	}{
+		"compatible": {
+			// The new schema just adds an optional attribute, which the
+			// conversion fills with null.
+			second: &configschema.Block{
+				Attributes: map[string]*configschema.Attribute{
+					"id": {
+						Type:     cty.String,
+						Computed: true,
+					},
+					"arn": {
+						Type:     cty.String,
+						Optional: true,
+					},
+				},
+			},
+		},
+		"incompatible": {
+			// The new schema changed the type of id to a list of bools, which
+			// a generated string can't convert into.
+			second: &configschema.Block{
+				Attributes: map[string]*configschema.Attribute{
+					"id": {
+						Type:     cty.List(cty.Bool),
+						Computed: true,
+					},
+				},
+			},
+			expectError: true,
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			generator := NewValueGenerator(GenerateOptions{
+				AlsoConformsTo: tc.second,
+			})
+
+			value, diags := generator.ComputedValuesForDataSource(target, ReplacementValue{}, schema)
+			if tc.expectError {
+				if !diags.HasErrors() {
+					t.Fatal("expected a conformance error, but got none")
+				}
+				if summary := diags[0].Description().Summary; summary != "Generated value does not conform to second schema" {
+					t.Errorf("wrong summary: %s", summary)
+				}
+			} else if diags.HasErrors() {
+				t.Fatalf("unexpected errors: %s", diags.Err())
+			}
+
+			// Either way the value itself still follows the operation schema.
+			if value.GetAttr("id").IsNull() {
+				t.Error("expected id to be generated regardless of the conformance check")
+			}
+		})
+	}
+}
+
+func TestValueGenerator_shuffleLists(t *testing.T) {
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"zones": {
+				Type:     cty.List(cty.String),
+				Optional: true,
+			},
+		},
+	}
+
+	original := []string{"a", "b", "c", "d", "e"}
+	target := cty.ObjectVal(map[string]cty.Value{
+		"zones": cty.ListVal([]cty.Value{
+			cty.StringVal("a"),
+			cty.StringVal("b"),
+			cty.StringVal("c"),
+			cty.StringVal("d"),
+			cty.StringVal("e"),
+		}),
+	})
+
+	shuffled := func(seed uint64) []string {
+		generator := NewValueGenerator(GenerateOptions{
+			Rand:         NewPCGSource(seed),
+			ShuffleLists: []string{"zones"},
+		})
+
+		value, diags := generator.ComputedValuesForDataSource(target, ReplacementValue{}, schema)
+		if diags.HasErrors() {
+			t.Fatalf("unexpected errors: %s", diags.Err())
+		}
+
+		var order []string
+		for _, elem := range value.GetAttr("zones").AsValueSlice() {
+			order = append(order, elem.AsString())
+		}
+		return order
+	}
+
+	// The same seed always gives the same order.
+	first, second := shuffled(42), shuffled(42)
+	for ix := range first {
+		if first[ix] != second[ix] {
+			t.Fatalf("expected a reproducible order under a fixed seed, got %v and %v", first, second)
+		}
+	}
+
+	// The result is still a permutation of the original elements.
+	counts := make(map[string]int)
+	for _, elem := range first {
+		counts[elem]++
+	}
+	for _, elem := range original {
+		if counts[elem] != 1 {
+			t.Fatalf("expected a permutation of %v, got %v", original, first)
+		}
+	}
+
+	// And some seed actually moves things around.
+	moved := false
+	for seed := uint64(1); seed <= 20 && !moved; seed++ {
+		for ix, elem := range shuffled(seed) {
+			if elem != original[ix] {
+				moved = true
+				break
+			}
+		}
+	}
+	if !moved {
+		t.Error("expected at least one seed to reorder the list")
+	}
+
+	// A sensitive-marked list still shuffles, with the mark carried over to
+	// the result, rather than panicking on the marked value.
+	markedSchema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"zones": {
+				Type:     cty.List(cty.String),
+				Computed: true,
+			},
+		},
+	}
+
+	generator := NewValueGenerator(GenerateOptions{
+		Rand: NewPCGSource(42),
+		MinCollectionLengths: map[string]int{
+			"zones": 2,
+		},
+		ShuffleLists: []string{"zones"},
+		SensitivePredicates: map[string]func(cty.Value) bool{
+			"zones": func(cty.Value) bool { return true },
+		},
+	})
+
+	value, diags := generator.ComputedValuesForDataSource(cty.ObjectVal(map[string]cty.Value{
+		"zones": cty.NullVal(cty.List(cty.String)),
+	}), ReplacementValue{}, markedSchema)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+	if !value.GetAttr("zones").HasMark(marks.Sensitive) {
+		t.Error("expected the shuffled list to keep its sensitive mark")
+	}
+}
+
+func TestValueGenerator_validators(t *testing.T) {
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"id": {
+				Type:     cty.String,
+				Computed: true,
+			},
+		},
+	}
+
+	target := cty.ObjectVal(map[string]cty.Value{
+		"id": cty.NullVal(cty.String),
+	})
+
+	t.Run("accepts_immediately", func(t *testing.T) {
+		testRand = rand.New(rand.NewSource(0))
+		defer func() {
+			testRand = nil
+		}()
+
+		generator := NewValueGenerator(GenerateOptions{
+			Validators: map[string]func(cty.Value) bool{
+				"id": func(cty.Value) bool { return true },
+			},
+		})
+
+		value, diags := generator.ComputedValuesForDataSource(target, ReplacementValue{}, schema)
+		if diags.HasErrors() {
+			t.Fatalf("unexpected errors: %s", diags.Err())
+		}
+
+		// An always-true validator doesn't disturb the usual first draw.
+		if id := value.GetAttr("id").AsString(); id != "ssnk9qhr" {
+			t.Errorf("expected the first draw to be accepted, got %q", id)
+		}
+	})
+
+	t.Run("forces_redraws", func(t *testing.T) {
+		testRand = rand.New(rand.NewSource(0))
+		defer func() {
+			testRand = nil
+		}()
+
+		// Reject the first few draws under this seed, so the generator has
+		// to try several times before it finds an acceptable value.
+		rejected := map[string]bool{"ssnk9qhr": true}
+		attempts := 0
+		generator := NewValueGenerator(GenerateOptions{
+			Validators: map[string]func(cty.Value) bool{
+				"id": func(value cty.Value) bool {
+					attempts++
+					return attempts > 3 && !rejected[value.AsString()]
+				},
+			},
+		})
+
+		value, diags := generator.ComputedValuesForDataSource(target, ReplacementValue{}, schema)
+		if diags.HasErrors() {
+			t.Fatalf("unexpected errors: %s", diags.Err())
+		}
+
+		if attempts < 4 {
+			t.Errorf("expected at least four validation attempts, got %d", attempts)
+		}
+		if id := value.GetAttr("id").AsString(); rejected[id] {
+			t.Errorf("expected a value the validator accepts, got %q", id)
+		}
+	})
+
+	t.Run("gives_up", func(t *testing.T) {
+		testRand = rand.New(rand.NewSource(0))
+		defer func() {
+			testRand = nil
+		}()
+
+		generator := NewValueGenerator(GenerateOptions{
+			Validators: map[string]func(cty.Value) bool{
+				"id": func(cty.Value) bool { return false },
+			},
+		})
+
+		_, diags := generator.ComputedValuesForDataSource(target, ReplacementValue{}, schema)
+		if !diags.HasErrors() {
+			t.Fatal("expected an error after exhausting the attempts, but got none")
+		}
+		if summary := diags[0].Description().Summary; summary != "Failed to generate valid value" {
+			t.Errorf("wrong summary: %s", summary)
+		}
+	})
+}
+
+func TestValueGenerator_overridesReplaceKnown(t *testing.T) {
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"id": {
+				Type:     cty.String,
+				Computed: true,
+			},
+		},
+	}
+
+	target := cty.ObjectVal(map[string]cty.Value{
+		"id": cty.StringVal("settled"),
+	})
+
+	with := ReplacementValue{
+		Value: cty.ObjectVal(map[string]cty.Value{
+			"id": cty.StringVal("overridden"),
+		}),
+	}
+
+	// By default a known computed value is settled, and the override is
+	// ignored.
+	value, diags := ComputedValuesForDataSource(target, with, schema)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+	if id := value.GetAttr("id").AsString(); id != "settled" {
+		t.Errorf("expected the known value to win by default, got %q", id)
+	}
+
+	generator := NewValueGenerator(GenerateOptions{
+		OverridesReplaceKnown: true,
+		WarnOnReplacedKnown:   true,
+	})
+
+	value, diags = generator.ComputedValuesForDataSource(target, with, schema)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+	if id := value.GetAttr("id").AsString(); id != "overridden" {
+		t.Errorf("expected the override to win with the option set, got %q", id)
+	}
+
+	if len(diags) != 1 {
+		t.Fatalf("expected exactly one warning, got %d diagnostics", len(diags))
+	}
+	if severity := diags[0].Severity(); severity != tfdiags.Warning {
+		t.Errorf("expected a warning, got %v", severity)
+	}
+	if summary := diags[0].Description().Summary; summary != "Override replaces known value" {
+		t.Errorf("wrong summary: %s", summary)
+	}
+}
+
+func TestValueGenerator_diagnosticFormatter(t *testing.T) {
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"id": {
+				Type:     cty.String,
+				Computed: true,
+			},
+		},
+	}
+
+	target := cty.ObjectVal(map[string]cty.Value{
+		"id": cty.NullVal(cty.String),
+	})
+
+	// An override of the wrong type produces the diagnostic to reformat.
+	with := ReplacementValue{
+		Value: cty.ObjectVal(map[string]cty.Value{
+			"id": cty.ListVal([]cty.Value{cty.StringVal("nope")}),
+		}),
+	}
+
+	generator := NewValueGenerator(GenerateOptions{
+		DiagnosticFormatter: func(summary, detail string) (string, string) {
+			return "mock: " + summary, "mock: " + detail
+		},
+	})
+
+	_, diags := generator.ComputedValuesForDataSource(target, with, schema)
+	if !diags.HasErrors() {
+		t.Fatal("expected a replacement error, but got none")
+	}
+
+	desc := diags[0].Description()
+	if !strings.HasPrefix(desc.Summary, "mock: ") {
+		t.Errorf("expected the formatter to rewrite the summary, got: %s", desc.Summary)
+	}
+	if !strings.HasPrefix(desc.Detail, "mock: ") {
+		t.Errorf("expected the formatter to rewrite the detail, got: %s", desc.Detail)
+	}
+
+	// The severity and cause of the underlying check are untouched.
+	if severity := diags[0].Severity(); severity != tfdiags.Error {
+		t.Errorf("expected the severity to survive, got %v", severity)
+	}
+	if cause := DiagnosticCauseOf(diags[0]); cause != CauseOverride {
+		t.Errorf("expected the cause to survive, got %v", cause)
+	}
+}
+
+func TestValueGenerator_minCollectionLengths(t *testing.T) {
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"zones": {
+				Type:     cty.List(cty.String),
+				Computed: true,
+			},
+			"tags": {
+				Type:     cty.Map(cty.String),
+				Computed: true,
+			},
+			"flags": {
+				Type:     cty.Set(cty.Bool),
+				Computed: true,
+			},
+		},
+	}
+
+	target := cty.ObjectVal(map[string]cty.Value{
+		"zones": cty.NullVal(cty.List(cty.String)),
+		"tags":  cty.NullVal(cty.Map(cty.String)),
+		"flags": cty.NullVal(cty.Set(cty.Bool)),
+	})
+
+	testRand = rand.New(rand.NewSource(0))
+	defer func() {
+		testRand = nil
+	}()
+
+	generator := NewValueGenerator(GenerateOptions{
+		MinCollectionLengths: map[string]int{
+			"zones": 2,
+			"tags":  2,
+			"flags": 3,
+		},
+	})
+
+	value, diags := generator.ComputedValuesForDataSource(target, ReplacementValue{}, schema)
+
+	if zones := value.GetAttr("zones"); zones.LengthInt() != 2 {
+		t.Errorf("expected 2 generated list elements, got %d", zones.LengthInt())
+	}
+	if tags := value.GetAttr("tags"); tags.LengthInt() != 2 {
+		t.Errorf("expected 2 generated map entries, got %d", tags.LengthInt())
+	}
+
+	// Booleans only have two distinct values, so a set of them can't hold
+	// three distinct elements.
+	if !diags.HasErrors() {
+		t.Fatal("expected an error for the boolean set, but got none")
+	}
+	if summary := diags[0].Description().Summary; summary != "Could not generate enough distinct set elements" {
+		t.Errorf("wrong summary: %s", summary)
+	}
+}
+
+func TestValueGenerator_ephemeralAttributes(t *testing.T) {
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"token": {
+				Type:     cty.String,
+				Computed: true,
+			},
+		},
+	}
+
+	target := cty.ObjectVal(map[string]cty.Value{
+		"token": cty.NullVal(cty.String),
+	})
+
+	t.Run("apply", func(t *testing.T) {
+		testRand = rand.New(rand.NewSource(0))
+		defer func() {
+			testRand = nil
+		}()
+
+		generator := NewValueGenerator(GenerateOptions{
+			Phase:               PhaseApply,
+			EphemeralAttributes: []string{"token"},
+		})
+
+		value, diags := generator.ComputedValuesForDataSource(target, ReplacementValue{}, schema)
+		if diags.HasErrors() {
+			t.Fatalf("unexpected errors: %s", diags.Err())
+		}
+
+		token := value.GetAttr("token")
+		if !token.IsKnown() {
+			t.Fatal("expected a known value during apply")
+		}
+		if !token.HasMark(marks.Ephemeral) {
+			t.Error("expected the value to carry the ephemeral mark during apply")
+		}
+	})
+
+	t.Run("plan", func(t *testing.T) {
+		generator := NewValueGenerator(GenerateOptions{
+			Phase:               PhasePlan,
+			EphemeralAttributes: []string{"token"},
+		})
+
+		value, diags := generator.ComputedValuesForDataSource(target, ReplacementValue{}, schema)
+		if diags.HasErrors() {
+			t.Fatalf("unexpected errors: %s", diags.Err())
+		}
+
+		token := value.GetAttr("token")
+		if token.IsKnown() {
+			t.Fatal("expected the value to stay unknown during plan")
+		}
+		if token.HasMark(marks.Ephemeral) {
+			t.Error("expected no ephemeral mark while the value is unknown")
+		}
+	})
+}
+
+func TestValueGenerator_canonicalizeMapKeys(t *testing.T) {
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"tags": {
+				Type:     cty.Map(cty.String),
+				Computed: true,
+			},
+		},
+	}
+
+	target := cty.ObjectVal(map[string]cty.Value{
+		"tags": cty.NullVal(cty.Map(cty.String)),
+	})
+
+	testRand = rand.New(rand.NewSource(0))
+	defer func() {
+		testRand = nil
+	}()
+
+	generator := NewValueGenerator(GenerateOptions{
+		MinCollectionLengths: map[string]int{
+			"tags": 2,
+		},
+		CanonicalizeMapKeys: strings.ToUpper,
+	})
+
+	value, diags := generator.ComputedValuesForDataSource(target, ReplacementValue{}, schema)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+
+	tags := value.GetAttr("tags")
+	if tags.LengthInt() != 2 {
+		t.Fatalf("expected 2 generated entries, got %d", tags.LengthInt())
+	}
+	for key := range tags.AsValueMap() {
+		if key != strings.ToUpper(key) {
+			t.Errorf("expected canonicalized keys, got %q", key)
+		}
+	}
+
+	// A hook that collapses every key to the same value can never satisfy a
+	// minimum of two entries; that must be an error, not an endless loop.
+	generator = NewValueGenerator(GenerateOptions{
+		MinCollectionLengths: map[string]int{
+			"tags": 2,
+		},
+		CanonicalizeMapKeys: func(string) string { return "constant" },
+	})
+
+	_, diags = generator.ComputedValuesForDataSource(target, ReplacementValue{}, schema)
+	if !diags.HasErrors() {
+		t.Fatal("expected an error for the collapsing hook, but got none")
+	}
+	if summary := diags[0].Description().Summary; summary != "Could not generate enough distinct map keys" {
+		t.Errorf("wrong summary: %s", summary)
+	}
+}
+
+func TestValueGenerator_reconcileTypeSkew(t *testing.T) {
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"port": {
+				// Stored as a number by older versions, now a string.
+				Type:     cty.String,
+				Computed: true,
+			},
+			"enabled": {
+				// Stored as a list by older versions, now a bool, with no
+				// sensible conversion between them.
+				Type:     cty.Bool,
+				Computed: true,
+			},
+		},
+	}
+
+	target := cty.ObjectVal(map[string]cty.Value{
+		"port":    cty.NumberIntVal(8080),
+		"enabled": cty.ListVal([]cty.Value{cty.StringVal("yes")}),
+	})
+
+	// Without the option the mismatched values are preserved as they are.
+	value, diags := ComputedValuesForDataSource(target, ReplacementValue{}, schema)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+	if port := value.GetAttr("port"); port.Type() != cty.Number {
+		t.Errorf("expected the number to be preserved by default, got %s", port.Type().FriendlyName())
+	}
+
+	generator := NewValueGenerator(GenerateOptions{
+		ReconcileTypeSkew: true,
+	})
+
+	value, diags = generator.ComputedValuesForDataSource(target, ReplacementValue{}, schema)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+
+	// The number converts cleanly to the new string type.
+	if port := value.GetAttr("port"); port.Type() != cty.String || port.AsString() != "8080" {
+		t.Errorf("expected the number to convert to \"8080\", got %s", port.GoString())
+	}
+
+	// The list can't become a bool, so a fresh value is generated and a
+	// warning raised.
+	if enabled := value.GetAttr("enabled"); enabled.Type() != cty.Bool || enabled.IsNull() {
+		t.Errorf("expected a generated bool, got %s", enabled.GoString())
+	}
+	if len(diags) != 1 || diags[0].Severity() != tfdiags.Warning {
+		t.Fatalf("expected exactly one warning, got %v", diags)
+	}
+	if summary := diags[0].Description().Summary; summary != "Mocked value regenerated after type change" {
+		t.Errorf("wrong summary: %s", summary)
+	}
+}
+
+func TestValueGenerator_linkOrder(t *testing.T) {
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"a": {
+				Type:     cty.String,
+				Computed: true,
+			},
+			"b": {
+				Type:     cty.String,
+				Computed: true,
+			},
+			"c": {
+				Type:     cty.String,
+				Computed: true,
+			},
+		},
+	}
+
+	target := cty.ObjectVal(map[string]cty.Value{
+		"a": cty.NullVal(cty.String),
+		"b": cty.NullVal(cty.String),
+		"c": cty.NullVal(cty.String),
+	})
+
+	testRand = rand.New(rand.NewSource(0))
+	defer func() {
+		testRand = nil
+	}()
+
+	// c depends on b, which depends on a, so b must resolve before c.
+	generator := NewValueGenerator(GenerateOptions{
+		Links: map[string]string{
+			"b": "a",
+			"c": "b",
+		},
+		LinkOrder: []string{"b", "c"},
+	})
+
+	value, diags := generator.ComputedValuesForDataSource(target, ReplacementValue{}, schema)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+
+	a := value.GetAttr("a").AsString()
+	if b := value.GetAttr("b").AsString(); b != a {
+		t.Errorf("expected b to equal a (%q), got %q", a, b)
+	}
+	if c := value.GetAttr("c").AsString(); c != a {
+		t.Errorf("expected c to follow the chain to a (%q), got %q", a, c)
+	}
+}
+
+func TestValueGenerator_failFast(t *testing.T) {
+	// Two computed attributes, both with invalid replacement values, so the
+	// default mode has two problems to report.
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"first": {
+				Type:     cty.String,
+				Computed: true,
+			},
+			"second": {
+				Type:     cty.String,
+				Computed: true,
+			},
+		},
+	}
+
+	target := cty.ObjectVal(map[string]cty.Value{
+		"first":  cty.NullVal(cty.String),
+		"second": cty.NullVal(cty.String),
+	})
+
+	with := ReplacementValue{
+		Value: cty.ObjectVal(map[string]cty.Value{
+			"first":  cty.ListValEmpty(cty.String),
+			"second": cty.ListValEmpty(cty.String),
+		}),
+	}
+
+	testRand = rand.New(rand.NewSource(0))
+	defer func() {
+		testRand = nil
+	}()
+
+	countErrors := func(diags tfdiags.Diagnostics) int {
+		errors := 0
+		for _, diag := range diags {
+			if diag.Severity() == tfdiags.Error {
+				errors++
+			}
+		}
+		return errors
+	}
+
+	// By default both problems are collected.
+	_, diags := ComputedValuesForDataSource(target, with, schema)
+	if countErrors(diags) != 2 {
+		t.Fatalf("expected two errors by default, got %d", countErrors(diags))
+	}
+
+	generator := NewValueGenerator(GenerateOptions{
+		FailFast: true,
+	})
+
+	value, diags := generator.ComputedValuesForDataSource(target, with, schema)
+	if countErrors(diags) != 1 {
+		t.Fatalf("expected exactly one error in fail-fast mode, got %d", countErrors(diags))
+	}
+
+	// The cut-short operation hands the target back unchanged.
+	if !value.RawEquals(target) {
+		t.Errorf("expected the target unchanged, got %s", value.GoString())
+	}
+}
+
+func TestValueGenerator_numberChoices(t *testing.T) {
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"port": {
+				Type:     cty.Number,
+				Computed: true,
+			},
+		},
+	}
+
+	target := cty.ObjectVal(map[string]cty.Value{
+		"port": cty.NullVal(cty.Number),
+	})
+
+	choices := map[int64]bool{80: true, 443: true, 8080: true}
+	generate := func(seed uint64) int64 {
+		generator := NewValueGenerator(GenerateOptions{
+			Rand: NewPCGSource(seed),
+			NumberChoices: map[string][]int64{
+				"port": {80, 443, 8080},
+			},
+		})
+
+		value, diags := generator.ComputedValuesForDataSource(target, ReplacementValue{}, schema)
+		if diags.HasErrors() {
+			t.Fatalf("unexpected errors: %s", diags.Err())
+		}
+
+		port, _ := value.GetAttr("port").AsBigFloat().Int64()
+		return port
+	}
+
+	first := generate(7)
+	if !choices[first] {
+		t.Fatalf("expected one of the allowed ports, got %d", first)
+	}
+	if second := generate(7); second != first {
+		t.Errorf("expected a reproducible choice under a fixed seed, got %d and %d", first, second)
+	}
+}
+
+func TestValueGenerator_advisoryAttributes(t *testing.T) {
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"signed_url": {
+				Type:     cty.String,
+				Computed: true,
+			},
+		},
+	}
+
+	target := cty.ObjectVal(map[string]cty.Value{
+		"signed_url": cty.NullVal(cty.String),
+	})
+
+	opts := GenerateOptions{
+		AdvisoryAttributes: []string{"signed_url"},
+	}
+
+	testRand = rand.New(rand.NewSource(0))
+	defer func() {
+		testRand = nil
+	}()
+
+	// A generated placeholder triggers the advisory warning.
+	value, diags := NewValueGenerator(opts).ComputedValuesForDataSource(target, ReplacementValue{}, schema)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+	if len(diags) != 1 || diags[0].Severity() != tfdiags.Warning {
+		t.Fatalf("expected exactly one warning, got %v", diags)
+	}
+	if summary := diags[0].Description().Summary; summary != "Mocked value may not satisfy provider validation" {
+		t.Errorf("wrong summary: %s", summary)
+	}
+	if value.GetAttr("signed_url").IsNull() {
+		t.Error("expected a placeholder value despite the warning")
+	}
+
+	// An explicit override is the caller's real-shaped value, so no warning.
+	with := ReplacementValue{
+		Value: cty.ObjectVal(map[string]cty.Value{
+			"signed_url": cty.StringVal("https://example.com/signed"),
+		}),
+	}
+	_, diags = NewValueGenerator(opts).ComputedValuesForDataSource(target, with, schema)
+	if len(diags) != 0 {
+		t.Errorf("expected no diagnostics for an overridden attribute, got %v", diags)
+	}
+}
+
+func TestValueGenerator_singleBlockListOverride(t *testing.T) {
+	schema := &configschema.Block{
+		BlockTypes: map[string]*configschema.NestedBlock{
+			"network": {
+				Nesting: configschema.NestingSingle,
+				Block: configschema.Block{
+					Attributes: map[string]*configschema.Attribute{
+						"id": {
+							Type:     cty.String,
+							Computed: true,
+						},
+					},
+				},
+			},
+		},
+	}
+
+	target := cty.ObjectVal(map[string]cty.Value{
+		"network": cty.ObjectVal(map[string]cty.Value{
+			"id": cty.NullVal(cty.String),
+		}),
+	})
+
+	// The override wraps the single block's object in a list, the way a
+	// NestingList block would be written.
+	with := ReplacementValue{
+		Value: cty.ObjectVal(map[string]cty.Value{
+			"network": cty.TupleVal([]cty.Value{
+				cty.ObjectVal(map[string]cty.Value{
+					"id": cty.StringVal("from-list"),
+				}),
+			}),
+		}),
+	}
+
+	t.Run("rejects_by_default", func(t *testing.T) {
+		testRand = rand.New(rand.NewSource(0))
+		defer func() {
+			testRand = nil
+		}()
+
+		_, diags := ComputedValuesForDataSource(target, with, schema)
+		if len(diags) != 1 || !diags.HasErrors() {
+			t.Fatalf("expected exactly one error, got %v", diags)
+		}
+
+		detail := diags[0].Description().Detail
+		if !strings.Contains(detail, "expected a single object at network") {
+			t.Errorf("expected the detail to name the single block, got: %s", detail)
+		}
+		if !strings.Contains(detail, "tuple of length 1") {
+			t.Errorf("expected the detail to describe the list, got: %s", detail)
+		}
+	})
+
+	t.Run("takes_first_element", func(t *testing.T) {
+		generator := NewValueGenerator(GenerateOptions{
+			TakeFirstForSingleBlocks: true,
+		})
+
+		value, diags := generator.ComputedValuesForDataSource(target, with, schema)
+		if diags.HasErrors() {
+			t.Fatalf("unexpected errors: %s", diags.Err())
+		}
+
+		if id := value.GetAttr("network").GetAttr("id"); id.AsString() != "from-list" {
+			t.Errorf("expected the first list element to win, got %s", id.GoString())
+		}
+	})
+
+	t.Run("list_blocks_unaffected", func(t *testing.T) {
+		// A list-shaped override for a genuine NestingList block is the
+		// usual object-type mistake, not the single-block one, and must not
+		// be silently broadcast even with the option enabled.
+		listSchema := &configschema.Block{
+			BlockTypes: map[string]*configschema.NestedBlock{
+				"network": {
+					Nesting: configschema.NestingList,
+					Block: configschema.Block{
+						Attributes: map[string]*configschema.Attribute{
+							"id": {
+								Type:     cty.String,
+								Computed: true,
+							},
+						},
+					},
+				},
+			},
+		}
+
+		listTarget := cty.ObjectVal(map[string]cty.Value{
+			"network": cty.ListVal([]cty.Value{
+				cty.ObjectVal(map[string]cty.Value{
+					"id": cty.NullVal(cty.String),
+				}),
+				cty.ObjectVal(map[string]cty.Value{
+					"id": cty.NullVal(cty.String),
+				}),
+			}),
+		})
+
+		listWith := ReplacementValue{
+			Value: cty.ObjectVal(map[string]cty.Value{
+				"network": cty.TupleVal([]cty.Value{
+					cty.ObjectVal(map[string]cty.Value{
+						"id": cty.StringVal("first"),
+					}),
+					cty.ObjectVal(map[string]cty.Value{
+						"id": cty.StringVal("second"),
+					}),
+				}),
+			}),
+		}
+
+		testRand = rand.New(rand.NewSource(0))
+		defer func() {
+			testRand = nil
+		}()
+
+		generator := NewValueGenerator(GenerateOptions{
+			TakeFirstForSingleBlocks: true,
+		})
+
+		_, diags := generator.ComputedValuesForDataSource(listTarget, listWith, listSchema)
+		if !diags.HasErrors() {
+			t.Fatal("expected an error for the list-shaped override, but got none")
+		}
+		if detail := diags[0].Description().Detail; !strings.Contains(detail, "expected an object type at network") {
+			t.Errorf("expected the usual object-type error, got: %s", detail)
+		}
+	})
+}
+
+func TestValueGenerator_partialResultOnError(t *testing.T) {
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"count": {
+				Type:     cty.Number,
+				Computed: true,
+			},
+			"id": {
+				Type:     cty.String,
+				Computed: true,
+			},
+		},
+	}
+
+	target := cty.ObjectVal(map[string]cty.Value{
+		"count": cty.NullVal(cty.Number),
+		"id":    cty.NullVal(cty.String),
+	})
+
+	// The override for count can't convert to a number, so that attribute
+	// fails while id generates normally.
+	with := ReplacementValue{
+		Value: cty.ObjectVal(map[string]cty.Value{
+			"count": cty.StringVal("not a number"),
+		}),
+	}
+
+	testRand = rand.New(rand.NewSource(0))
+	defer func() {
+		testRand = nil
+	}()
+
+	value, diags := ComputedValuesForDataSource(target, with, schema)
+	if !diags.HasErrors() {
+		t.Fatal("expected an error diagnostic for the broken override")
+	}
+
+	// Despite the error, the result is a valid object with every attribute
+	// filled: the broken attribute falls back to a generated value, and the
+	// untouched one generates as usual.
+	if count := value.GetAttr("count"); count.IsNull() || !count.IsKnown() {
+		t.Errorf("expected a best-effort value for count, got %s", count.GoString())
+	}
+	if id := value.GetAttr("id"); id.IsNull() || !id.IsKnown() || id.AsString() == "" {
+		t.Errorf("expected a generated value for id, got %s", id.GoString())
+	}
+}
+
+func TestValueGenerator_diagnosticsTransform(t *testing.T) {
+	schema := &configschema.Block{
+		BlockTypes: map[string]*configschema.NestedBlock{
+			"endpoint": {
+				Nesting: configschema.NestingSet,
+				Block: configschema.Block{
+					Attributes: map[string]*configschema.Attribute{
+						"name": {
+							Type:     cty.String,
+							Required: true,
+						},
+						"id": {
+							Type:     cty.String,
+							Computed: true,
+						},
+					},
+				},
+			},
+		},
+	}
+
+	target := cty.ObjectVal(map[string]cty.Value{
+		"endpoint": cty.SetVal([]cty.Value{
+			cty.ObjectVal(map[string]cty.Value{
+				"name": cty.StringVal("first"),
+				"id":   cty.NullVal(cty.String),
+			}),
+			cty.ObjectVal(map[string]cty.Value{
+				"name": cty.StringVal("second"),
+				"id":   cty.NullVal(cty.String),
+			}),
+		}),
+	})
+
+	// A broken override for id is broadcast to every element of the set,
+	// producing one identical diagnostic per element.
+	with := ReplacementValue{
+		Value: cty.ObjectVal(map[string]cty.Value{
+			"endpoint": cty.ObjectVal(map[string]cty.Value{
+				"id": cty.TupleVal([]cty.Value{cty.True}),
+			}),
+		}),
+	}
+
+	testRand = rand.New(rand.NewSource(0))
+	defer func() {
+		testRand = nil
+	}()
+
+	// Without the hook, each set element reports the problem separately.
+	_, diags := ComputedValuesForDataSource(target, with, schema)
+	if len(diags) != 2 {
+		t.Fatalf("expected one diagnostic per set element, got %v", diags)
+	}
+
+	generator := NewValueGenerator(GenerateOptions{
+		DiagnosticsTransform: func(diags tfdiags.Diagnostics) tfdiags.Diagnostics {
+			seen := make(map[string]bool)
+			var deduped tfdiags.Diagnostics
+			for _, diag := range diags {
+				desc := diag.Description()
+				key := desc.Summary + "\n" + desc.Detail
+				if seen[key] {
+					continue
+				}
+				seen[key] = true
+				deduped = deduped.Append(diag)
+			}
+			return deduped
+		},
+	})
+
+	_, diags = generator.ComputedValuesForDataSource(target, with, schema)
+	if len(diags) != 1 {
+		t.Fatalf("expected the hook to dedup identical diagnostics, got %v", diags)
+	}
+	if !diags.HasErrors() {
+		t.Error("expected the surviving diagnostic to still be an error")
+	}
+}
+
+func TestValueGenerator_stableIdentity(t *testing.T) {
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"id": {
+				Type:     cty.String,
+				Computed: true,
+			},
+			"arn": {
+				Type:     cty.String,
+				Computed: true,
+			},
+		},
+	}
+
+	target := cty.ObjectVal(map[string]cty.Value{
+		"id":  cty.NullVal(cty.String),
+		"arn": cty.NullVal(cty.String),
+	})
+
+	applyFor := func(identity string) cty.Value {
+		generator := NewValueGenerator(GenerateOptions{
+			StableIdentity: identity,
+		})
+
+		planned, diags := generator.PlanComputedValuesForResource(target, schema)
+		if diags.HasErrors() {
+			t.Fatalf("unexpected errors: %s", diags.Err())
+		}
+		applied, diags := generator.ApplyComputedValuesForResource(planned, ReplacementValue{}, schema)
+		if diags.HasErrors() {
+			t.Fatalf("unexpected errors: %s", diags.Err())
+		}
+		return applied
+	}
+
+	// Values are keyed on identity and path, not on draw order, so two
+	// separate plan-then-apply rounds over the same identity agree even with
+	// sibling attributes in play.
+	first := applyFor("aws_instance.primary")
+	second := applyFor("aws_instance.primary")
+	if !first.RawEquals(second) {
+		t.Errorf("expected identical values for the same identity\nfirst:  %s\nsecond: %s", first.GoString(), second.GoString())
+	}
+
+	// A different identity draws from a different key, so the values differ.
+	other := applyFor("aws_instance.secondary")
+	if first.GetAttr("id").RawEquals(other.GetAttr("id")) {
+		t.Errorf("expected different identities to generate different values, both got %s", first.GetAttr("id").GoString())
+	}
+}
+
+func TestValueGenerator_emptyStringPaths(t *testing.T) {
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"description": {
+				Type:     cty.String,
+				Computed: true,
+			},
+			"id": {
+				Type:     cty.String,
+				Computed: true,
+			},
+		},
+	}
+
+	target := cty.ObjectVal(map[string]cty.Value{
+		"description": cty.NullVal(cty.String),
+		"id":          cty.NullVal(cty.String),
+	})
+
+	generator := NewValueGenerator(GenerateOptions{
+		EmptyStringPaths: []string{"description"},
+	})
+
+	testRand = rand.New(rand.NewSource(0))
+	defer func() {
+		testRand = nil
+	}()
+
+	value, diags := generator.ComputedValuesForDataSource(target, ReplacementValue{}, schema)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+
+	if description := value.GetAttr("description"); description.AsString() != "" {
+		t.Errorf("expected an empty string for description, got %s", description.GoString())
+	}
+
+	// Paths not listed still receive the usual random fill.
+	if id := value.GetAttr("id"); len(id.AsString()) != 8 {
+		t.Errorf("expected a random string for id, got %s", id.GoString())
+	}
+}
+
+func TestValueGenerator_requiredWith(t *testing.T) {
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"access_key": {
+				Type:     cty.String,
+				Computed: true,
+			},
+			"secret_key": {
+				Type:     cty.String,
+				Computed: true,
+			},
+		},
+	}
+
+	target := cty.ObjectVal(map[string]cty.Value{
+		"access_key": cty.NullVal(cty.String),
+		"secret_key": cty.NullVal(cty.String),
+	})
+
+	opts := GenerateOptions{
+		RequiredWith: [][]string{
+			{"access_key", "secret_key"},
+		},
+	}
+
+	testRand = rand.New(rand.NewSource(0))
+	defer func() {
+		testRand = nil
+	}()
+
+	// Overriding only one member of the group raises a warning naming the
+	// one that was missed.
+	partial := ReplacementValue{
+		Value: cty.ObjectVal(map[string]cty.Value{
+			"access_key": cty.StringVal("AKIA"),
+		}),
+	}
+	_, diags := NewValueGenerator(opts).ComputedValuesForDataSource(target, partial, schema)
+	if len(diags) != 1 || diags[0].Severity() != tfdiags.Warning {
+		t.Fatalf("expected exactly one warning, got %v", diags)
+	}
+	if detail := diags[0].Description().Detail; !strings.Contains(detail, "sets access_key but not secret_key") {
+		t.Errorf("expected the warning to name the missing attribute, got: %s", detail)
+	}
+
+	// Overriding the whole group is fine.
+	complete := ReplacementValue{
+		Value: cty.ObjectVal(map[string]cty.Value{
+			"access_key": cty.StringVal("AKIA"),
+			"secret_key": cty.StringVal("shhh"),
+		}),
+	}
+	if _, diags := NewValueGenerator(opts).ComputedValuesForDataSource(target, complete, schema); len(diags) != 0 {
+		t.Errorf("expected no diagnostics for a complete group, got %v", diags)
+	}
+}
+
+func TestValueGenerator_priorSchemaComputedFlags(t *testing.T) {
+	prior := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"id": {
+				Type:     cty.String,
+				Computed: true,
+			},
+			"name": {
+				Type:     cty.String,
+				Optional: true,
+			},
+		},
+	}
+
+	current := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"id": {
+				Type:     cty.String,
+				Optional: true,
+			},
+			"name": {
+				Type:     cty.String,
+				Computed: true,
+			},
+		},
+	}
+
+	// The target was generated under the prior schema: id holds a generated
+	// placeholder, and name was configured.
+	target := cty.ObjectVal(map[string]cty.Value{
+		"id":   cty.StringVal("generated-under-old-schema"),
+		"name": cty.NullVal(cty.String),
+	})
+
+	generator := NewValueGenerator(GenerateOptions{
+		PriorSchema: prior,
+	})
+
+	testRand = rand.New(rand.NewSource(0))
+	defer func() {
+		testRand = nil
+	}()
+
+	value, diags := generator.ComputedValuesForDataSource(target, ReplacementValue{}, current)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+	if len(diags) != 2 {
+		t.Fatalf("expected one warning per changed flag, got %v", diags)
+	}
+
+	summaries := make(map[string]bool)
+	for _, diag := range diags {
+		summaries[diag.Description().Summary] = true
+	}
+	if !summaries["Attribute is now computed"] || !summaries["Attribute is no longer computed"] {
+		t.Errorf("expected warnings in both directions, got %v", summaries)
+	}
+
+	// The current schema wins: name is filled as a computed attribute, and
+	// id is left exactly as the target supplied it.
+	if name := value.GetAttr("name"); name.IsNull() {
+		t.Error("expected a generated value for the newly computed name")
+	}
+	if id := value.GetAttr("id"); id.AsString() != "generated-under-old-schema" {
+		t.Errorf("expected id to be preserved, got %s", id.GoString())
+	}
+}
+
+func TestValueGenerator_hybridNesting(t *testing.T) {
+	// A hybrid schema: a nested block holding an object-typed attribute
+	// whose nested type itself contains another nested object with computed
+	// leaves at every level.
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"id": {
+				Type:     cty.String,
+				Computed: true,
+			},
+		},
+		BlockTypes: map[string]*configschema.NestedBlock{
+			"spec": {
+				Nesting: configschema.NestingList,
+				Block: configschema.Block{
+					Attributes: map[string]*configschema.Attribute{
+						"fingerprint": {
+							Type:     cty.String,
+							Computed: true,
+						},
+						"metadata": {
+							NestedType: &configschema.Object{
+								Nesting: configschema.NestingSingle,
+								Attributes: map[string]*configschema.Attribute{
+									"uid": {
+										Type:     cty.String,
+										Computed: true,
+									},
+									"owner": {
+										NestedType: &configschema.Object{
+											Nesting: configschema.NestingSingle,
+											Attributes: map[string]*configschema.Attribute{
+												"token": {
+													Type:     cty.String,
+													Computed: true,
+												},
+											},
+										},
+										Optional: true,
+										Computed: true,
+									},
+								},
+							},
+							Optional: true,
+							Computed: true,
+						},
+					},
+				},
+			},
+		},
+	}
+
+	target := cty.ObjectVal(map[string]cty.Value{
+		"id": cty.NullVal(cty.String),
+		"spec": cty.ListVal([]cty.Value{
+			cty.ObjectVal(map[string]cty.Value{
+				"fingerprint": cty.NullVal(cty.String),
+				"metadata": cty.ObjectVal(map[string]cty.Value{
+					"uid": cty.NullVal(cty.String),
+					"owner": cty.ObjectVal(map[string]cty.Value{
+						"token": cty.NullVal(cty.String),
+					}),
+				}),
+			}),
+		}),
+	})
+
+	testRand = rand.New(rand.NewSource(0))
+	defer func() {
+		testRand = nil
+	}()
+
+	value, diags := ComputedValuesForDataSource(target, ReplacementValue{}, schema)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+
+	// Every computed leaf is filled, all the way down.
+	spec := value.GetAttr("spec").Index(cty.NumberIntVal(0))
+	metadata := spec.GetAttr("metadata")
+	for name, leaf := range map[string]cty.Value{
+		"id":          value.GetAttr("id"),
+		"fingerprint": spec.GetAttr("fingerprint"),
+		"uid":         metadata.GetAttr("uid"),
+		"token":       metadata.GetAttr("owner").GetAttr("token"),
+	} {
+		if leaf.IsNull() || !leaf.IsKnown() {
+			t.Errorf("expected a generated value for %s, got %s", name, leaf.GoString())
+		}
+	}
+}
+
+func TestValueGenerator_derivedBools(t *testing.T) {
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"name": {
+				Type:     cty.String,
+				Optional: true,
+			},
+			"enabled": {
+				Type:     cty.Bool,
+				Computed: true,
+			},
+		},
+	}
+
+	derive := func(name string) bool {
+		generator := NewValueGenerator(GenerateOptions{
+			DerivedBools: map[string]string{
+				"enabled": "name",
+			},
+		})
+
+		target := cty.ObjectVal(map[string]cty.Value{
+			"name":    cty.StringVal(name),
+			"enabled": cty.NullVal(cty.Bool),
+		})
+
+		value, diags := generator.ComputedValuesForDataSource(target, ReplacementValue{}, schema)
+		if diags.HasErrors() {
+			t.Fatalf("unexpected errors: %s", diags.Err())
+		}
+		return value.GetAttr("enabled").True()
+	}
+
+	// The same sibling value always derives the same bool.
+	first := derive("primary")
+	if second := derive("primary"); second != first {
+		t.Errorf("expected a consistent bool for equal inputs, got %t and %t", first, second)
+	}
+
+	// Different sibling values vary the bool. Any single pair might collide
+	// on the same hash parity, so look across a handful of inputs.
+	varied := false
+	for _, name := range []string{"secondary", "tertiary", "quaternary", "quinary"} {
+		if derive(name) != first {
+			varied = true
+			break
+		}
+	}
+	if !varied {
+		t.Error("expected different inputs to derive different bools")
+	}
+
+	// A marked collection at the source path must hash rather than panic,
+	// and derive the same bool as its unmarked equivalent.
+	markedSchema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"zones": {
+				Type:     cty.List(cty.String),
+				Optional: true,
+			},
+			"enabled": {
+				Type:     cty.Bool,
+				Computed: true,
+			},
+		},
+	}
+
+	deriveFromZones := func(zones cty.Value) bool {
+		generator := NewValueGenerator(GenerateOptions{
+			DerivedBools: map[string]string{
+				"enabled": "zones",
+			},
+		})
+
+		value, diags := generator.ComputedValuesForDataSource(cty.ObjectVal(map[string]cty.Value{
+			"zones":   zones,
+			"enabled": cty.NullVal(cty.Bool),
+		}), ReplacementValue{}, markedSchema)
+		if diags.HasErrors() {
+			t.Fatalf("unexpected errors: %s", diags.Err())
+		}
+		return value.GetAttr("enabled").True()
+	}
+
+	zones := cty.ListVal([]cty.Value{cty.StringVal("us-east-1a")})
+	if deriveFromZones(zones.Mark(marks.Sensitive)) != deriveFromZones(zones) {
+		t.Error("expected a marked source to derive the same bool as its unmarked equivalent")
+	}
+}
+
+func TestValueGenerator_modulePath(t *testing.T) {
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"id": {
+				Type:     cty.String,
+				Computed: true,
+			},
+		},
+	}
+
+	target := cty.ObjectVal(map[string]cty.Value{
+		"id": cty.NullVal(cty.String),
+	})
+
+	generate := func(modulePath string) string {
+		generator := NewValueGenerator(GenerateOptions{
+			ModulePath: modulePath,
+		})
+
+		value, diags := generator.ComputedValuesForDataSource(target, ReplacementValue{}, schema)
+		if diags.HasErrors() {
+			t.Fatalf("unexpected errors: %s", diags.Err())
+		}
+		return value.GetAttr("id").AsString()
+	}
+
+	testRand = rand.New(rand.NewSource(0))
+	defer func() {
+		testRand = nil
+	}()
+
+	first := generate("module.network")
+	second := generate("module.storage")
+
+	if !strings.HasPrefix(first, "module.network:") {
+		t.Errorf("expected the module path as a prefix, got %q", first)
+	}
+	if !strings.HasPrefix(second, "module.storage:") {
+		t.Errorf("expected the module path as a prefix, got %q", second)
+	}
+}
+
+// garbageFaker implements Faker with output that violates the string guards,
+// standing in for a misbehaving caller-supplied implementation.
+type garbageFaker struct{}
+
+func (garbageFaker) Name() string     { return "bad\xff\xfebytes" }
+func (garbageFaker) Email() string    { return "UPPER@EXAMPLE.COM" }
+func (garbageFaker) Hostname() string { return "host" }
+func (garbageFaker) UUID() string     { return "uuid" }
+
+func TestValueGenerator_validateGeneratedStrings(t *testing.T) {
+	t.Run("invalid_utf8", func(t *testing.T) {
+		schema := &configschema.Block{
+			Attributes: map[string]*configschema.Attribute{
+				"name": {
+					Type:     cty.String,
+					Computed: true,
+				},
+			},
+		}
+		target := cty.ObjectVal(map[string]cty.Value{
+			"name": cty.NullVal(cty.String),
+		})
+
+		generator := NewValueGenerator(GenerateOptions{
+			Faker:                    garbageFaker{},
+			ValidateGeneratedStrings: true,
+		})
+
+		_, diags := generator.ComputedValuesForDataSource(target, ReplacementValue{}, schema)
+		if len(diags) != 1 || !diags.HasErrors() {
+			t.Fatalf("expected exactly one error, got %v", diags)
+		}
+		if summary := diags[0].Description().Summary; summary != "Generated string is not valid UTF-8" {
+			t.Errorf("wrong summary: %s", summary)
+		}
+	})
+
+	t.Run("outside_charset", func(t *testing.T) {
+		schema := &configschema.Block{
+			Attributes: map[string]*configschema.Attribute{
+				"email": {
+					Type:     cty.String,
+					Computed: true,
+				},
+			},
+		}
+		target := cty.ObjectVal(map[string]cty.Value{
+			"email": cty.NullVal(cty.String),
+		})
+
+		generator := NewValueGenerator(GenerateOptions{
+			Faker:                    garbageFaker{},
+			ValidateGeneratedStrings: true,
+			Charset:                  "abcdefghijklmnopqrstuvwxyz0123456789@.",
+		})
+
+		_, diags := generator.ComputedValuesForDataSource(target, ReplacementValue{}, schema)
+		if len(diags) != 1 || !diags.HasErrors() {
+			t.Fatalf("expected exactly one error, got %v", diags)
+		}
+		if summary := diags[0].Description().Summary; summary != "Generated string outside allowed charset" {
+			t.Errorf("wrong summary: %s", summary)
+		}
+	})
+
+	t.Run("templated_strings_checked", func(t *testing.T) {
+		// The guard covers every generation branch, not just the default
+		// random string: a template that expands outside the charset is
+		// reported too.
+		schema := &configschema.Block{
+			Attributes: map[string]*configschema.Attribute{
+				"id": {
+					Type:     cty.String,
+					Computed: true,
+				},
+			},
+		}
+		target := cty.ObjectVal(map[string]cty.Value{
+			"id": cty.NullVal(cty.String),
+		})
+
+		generator := NewValueGenerator(GenerateOptions{
+			Templates: map[string]string{
+				"id": "FIXED",
+			},
+			ValidateGeneratedStrings: true,
+			Charset:                  "abcdefghijklmnopqrstuvwxyz0123456789",
+		})
+
+		_, diags := generator.ComputedValuesForDataSource(target, ReplacementValue{}, schema)
+		if len(diags) != 1 || !diags.HasErrors() {
+			t.Fatalf("expected exactly one error, got %v", diags)
+		}
+		if summary := diags[0].Description().Summary; summary != "Generated string outside allowed charset" {
+			t.Errorf("wrong summary: %s", summary)
+		}
+	})
+
+	t.Run("built_in_generators_pass", func(t *testing.T) {
+		schema := &configschema.Block{
+			Attributes: map[string]*configschema.Attribute{
+				"id": {
+					Type:     cty.String,
+					Computed: true,
+				},
+			},
+		}
+		target := cty.ObjectVal(map[string]cty.Value{
+			"id": cty.NullVal(cty.String),
+		})
+
+		generator := NewValueGenerator(GenerateOptions{
+			ValidateGeneratedStrings: true,
+			Charset:                  "abcdefghijklmnopqrstuvwxyz0123456789",
+		})
+
+		testRand = rand.New(rand.NewSource(0))
+		defer func() {
+			testRand = nil
+		}()
+
+		if _, diags := generator.ComputedValuesForDataSource(target, ReplacementValue{}, schema); len(diags) != 0 {
+			t.Errorf("expected no diagnostics, got %v", diags)
+		}
+	})
+}
+
+func TestValueGenerator_eachKey(t *testing.T) {
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"id": {
+				Type:     cty.String,
+				Computed: true,
+			},
+		},
+	}
+
+	target := cty.ObjectVal(map[string]cty.Value{
+		"id": cty.NullVal(cty.String),
+	})
+
+	generate := func(key string) string {
+		generator := NewValueGenerator(GenerateOptions{
+			EachKey: key,
+			Templates: map[string]string{
+				"id": "mock-${each.key}",
+			},
+		})
+
+		value, diags := generator.ComputedValuesForDataSource(target, ReplacementValue{}, schema)
+		if diags.HasErrors() {
+			t.Fatalf("unexpected errors: %s", diags.Err())
+		}
+		return value.GetAttr("id").AsString()
+	}
+
+	if id := generate("primary"); id != "mock-primary" {
+		t.Errorf("expected the for_each key in the id, got %q", id)
+	}
+	if id := generate("secondary"); id != "mock-secondary" {
+		t.Errorf("expected the for_each key in the id, got %q", id)
+	}
+}
+
+func TestValueGenerator_distinctAttributes(t *testing.T) {
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"id": {
+				Type:     cty.String,
+				Computed: true,
+			},
+			"secondary_id": {
+				Type:     cty.String,
+				Computed: true,
+			},
+		},
+	}
+
+	target := cty.ObjectVal(map[string]cty.Value{
+		"id":           cty.NullVal(cty.String),
+		"secondary_id": cty.NullVal(cty.String),
+	})
+
+	testRand = rand.New(rand.NewSource(0))
+	defer func() {
+		testRand = nil
+	}()
+
+	// Templates pinning both attributes to the same value stand in for an
+	// unlucky seed colliding two generated values.
+	generator := NewValueGenerator(GenerateOptions{
+		DistinctAttributes: []string{"id", "secondary_id"},
+		Templates: map[string]string{
+			"id":           "collision",
+			"secondary_id": "collision",
+		},
+	})
+
+	_, diags := generator.ComputedValuesForDataSource(target, ReplacementValue{}, schema)
+	if len(diags) != 1 || !diags.HasErrors() {
+		t.Fatalf("expected exactly one error, got %v", diags)
+	}
+	if summary := diags[0].Description().Summary; summary != "Computed values are not distinct" {
+		t.Errorf("wrong summary: %s", summary)
+	}
+
+	// Without the collision the check stays quiet.
+	generator = NewValueGenerator(GenerateOptions{
+		DistinctAttributes: []string{"id", "secondary_id"},
+	})
+	if _, diags := generator.ComputedValuesForDataSource(target, ReplacementValue{}, schema); len(diags) != 0 {
+		t.Errorf("expected no diagnostics for distinct values, got %v", diags)
+	}
+
+	// Marked collection values must still be comparable: two identical
+	// sensitive lists collide rather than panicking the check.
+	markedSchema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"zones": {
+				Type:     cty.List(cty.String),
+				Optional: true,
+			},
+			"backup_zones": {
+				Type:     cty.List(cty.String),
+				Optional: true,
+			},
+		},
+	}
+	markedTarget := cty.ObjectVal(map[string]cty.Value{
+		"zones": cty.ListVal([]cty.Value{
+			cty.StringVal("us-east-1a"),
+		}).Mark(marks.Sensitive),
+		"backup_zones": cty.ListVal([]cty.Value{
+			cty.StringVal("us-east-1a"),
+		}).Mark(marks.Sensitive),
+	})
+
+	generator = NewValueGenerator(GenerateOptions{
+		DistinctAttributes: []string{"zones", "backup_zones"},
+	})
+	_, diags = generator.ComputedValuesForDataSource(markedTarget, ReplacementValue{}, markedSchema)
+	if len(diags) != 1 || !diags.HasErrors() {
+		t.Fatalf("expected exactly one error for the marked collision, got %v", diags)
+	}
+	if summary := diags[0].Description().Summary; summary != "Computed values are not distinct" {
+		t.Errorf("wrong summary: %s", summary)
+	}
+}
+
+func TestValueGenerator_numberRanges(t *testing.T) {
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"port": {
+				Type:     cty.Number,
+				Computed: true,
+			},
+		},
+	}
+
+	target := cty.ObjectVal(map[string]cty.Value{
+		"port": cty.NullVal(cty.Number),
+	})
+
+	opts := GenerateOptions{
+		NumberRanges: map[string]NumberRange{
+			"port": {Min: 1024, Max: 65535},
+		},
+	}
+
+	testRand = rand.New(rand.NewSource(0))
+	defer func() {
+		testRand = nil
+	}()
+
+	// Generated numbers land inside the declared range.
+	value, diags := NewValueGenerator(opts).ComputedValuesForDataSource(target, ReplacementValue{}, schema)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+	if port, _ := value.GetAttr("port").AsBigFloat().Int64(); port < 1024 || port > 65535 {
+		t.Errorf("expected a port within the declared range, got %d", port)
+	}
+
+	// An override outside the range is reported.
+	with := ReplacementValue{
+		Value: cty.ObjectVal(map[string]cty.Value{
+			"port": cty.NumberIntVal(80),
+		}),
+	}
+	value, diags = NewValueGenerator(opts).ComputedValuesForDataSource(target, with, schema)
+	if len(diags) != 1 || !diags.HasErrors() {
+		t.Fatalf("expected exactly one error, got %v", diags)
+	}
+	if summary := diags[0].Description().Summary; summary != "Override out of range" {
+		t.Errorf("wrong summary: %s", summary)
+	}
+
+	// The override still wins; the range check is a report, not a rewrite.
+	if port, _ := value.GetAttr("port").AsBigFloat().Int64(); port != 80 {
+		t.Errorf("expected the override value despite the diagnostic, got %d", port)
+	}
+
+	// Extreme but valid bounds whose width overflows int64 must not panic,
+	// and the draw still lands inside them.
+	wide := GenerateOptions{
+		NumberRanges: map[string]NumberRange{
+			"port": {Min: -1 << 62, Max: 1 << 62},
+		},
+	}
+	value, diags = NewValueGenerator(wide).ComputedValuesForDataSource(target, ReplacementValue{}, schema)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+	if port, _ := value.GetAttr("port").AsBigFloat().Int64(); port < -1<<62 || port > 1<<62 {
+		t.Errorf("expected a value within the wide range, got %d", port)
+	}
+}
+
+func TestValueGenerator_anonymizeAttributes(t *testing.T) {
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"owner": {
+				Type:     cty.String,
+				Computed: true,
+			},
+		},
+	}
+
+	target := cty.ObjectVal(map[string]cty.Value{
+		"owner": cty.NullVal(cty.String),
+	})
+
+	anonymize := func(input string) string {
+		generator := NewValueGenerator(GenerateOptions{
+			AnonymizeAttributes: []string{"owner"},
+		})
+
+		with := ReplacementValue{
+			Value: cty.ObjectVal(map[string]cty.Value{
+				"owner": cty.StringVal(input),
+			}),
+		}
+		value, diags := generator.ComputedValuesForDataSource(target, with, schema)
+		if diags.HasErrors() {
+			t.Fatalf("unexpected errors: %s", diags.Err())
+		}
+		return value.GetAttr("owner").AsString()
+	}
+
+	first := anonymize("alice@example.com")
+	if first == "alice@example.com" {
+		t.Error("expected the original value to be replaced")
+	}
+	if !strings.HasPrefix(first, "anon-") {
+		t.Errorf("expected a pseudonym, got %q", first)
+	}
+
+	// Equal inputs map to the same pseudonym, distinct inputs to different
+	// ones.
+	if second := anonymize("alice@example.com"); second != first {
+		t.Errorf("expected a consistent pseudonym for equal inputs, got %q and %q", first, second)
+	}
+	if other := anonymize("bob@example.com"); other == first {
+		t.Errorf("expected a different pseudonym for a different input, both got %q", other)
+	}
+
+	// A marked value (here via a sensitive predicate) must still anonymize,
+	// with the mark carried over to the pseudonym.
+	generator := NewValueGenerator(GenerateOptions{
+		AnonymizeAttributes: []string{"owner"},
+		SensitivePredicates: map[string]func(cty.Value) bool{
+			"owner": func(cty.Value) bool { return true },
+		},
+	})
+	with := ReplacementValue{
+		Value: cty.ObjectVal(map[string]cty.Value{
+			"owner": cty.StringVal("alice@example.com"),
+		}),
+	}
+	value, diags := generator.ComputedValuesForDataSource(target, with, schema)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+	owner := value.GetAttr("owner")
+	if !owner.HasMark(marks.Sensitive) {
+		t.Error("expected the pseudonym to keep the sensitive mark")
+	}
+	if unmarked, _ := owner.Unmark(); unmarked.AsString() != first {
+		t.Errorf("expected the marked value to anonymize to the same pseudonym %q, got %q", first, unmarked.AsString())
+	}
+}
+
+func TestValueGenerator_transformsOverUnknowns(t *testing.T) {
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"id": {
+				Type:     cty.String,
+				Computed: true,
+			},
+			"tags": {
+				Type:     cty.Map(cty.String),
+				Optional: true,
+			},
+		},
+		BlockTypes: map[string]*configschema.NestedBlock{
+			"network": {
+				Nesting: configschema.NestingList,
+				Block: configschema.Block{
+					Attributes: map[string]*configschema.Attribute{
+						"cidr": {
+							Type:     cty.String,
+							Optional: true,
+						},
+						"gateway": {
+							Type:     cty.String,
+							Computed: true,
+						},
+					},
+				},
+			},
+		},
+	}
+
+	target := cty.ObjectVal(map[string]cty.Value{
+		"id": cty.NullVal(cty.String),
+		"tags": cty.MapVal(map[string]cty.Value{
+			"env": cty.StringVal("test"),
+		}),
+		"network": cty.ListVal([]cty.Value{
+			cty.ObjectVal(map[string]cty.Value{
+				"cidr":    cty.StringVal("10.0.0.0/16"),
+				"gateway": cty.NullVal(cty.String),
+			}),
+		}),
+	})
+
+	planned, diags := PlanComputedValuesForResource(target, schema)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+
+	// A planned value mixes unknowns with known collections, and must still
+	// compose with a downstream cty.Transform pipeline.
+	transformed, err := cty.Transform(planned, func(path cty.Path, value cty.Value) (cty.Value, error) {
+		if value.IsKnown() && !value.IsNull() && value.Type() == cty.String {
+			return cty.StringVal(strings.ToUpper(value.AsString())), nil
+		}
+		return value, nil
+	})
+	if err != nil {
+		t.Fatalf("transform over generated value failed: %s", err)
+	}
+
+	if !transformed.Type().Equals(planned.Type()) {
+		t.Errorf("transform changed the value type:\nbefore: %#v\nafter:  %#v", planned.Type(), transformed.Type())
+	}
+	if cidr := transformed.GetAttr("network").Index(cty.NumberIntVal(0)).GetAttr("cidr"); cidr.AsString() != "10.0.0.0/16" {
+		t.Errorf("expected the known cidr to survive the transform, got %s", cidr.GoString())
+	}
+	if gateway := transformed.GetAttr("network").Index(cty.NumberIntVal(0)).GetAttr("gateway"); gateway.IsKnown() {
+		t.Errorf("expected the computed gateway to stay unknown, got %s", gateway.GoString())
+	}
+}
+
+func TestValueGenerator_incrementingAttributes(t *testing.T) {
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"version": {
+				Type:     cty.Number,
+				Computed: true,
+			},
+		},
+	}
+
+	target := cty.ObjectVal(map[string]cty.Value{
+		"version": cty.NullVal(cty.Number),
+	})
+
+	// The create step has no prior state, so the counter gets its starting
+	// value.
+	created, diags := NewValueGenerator(GenerateOptions{
+		IncrementingAttributes: []string{"version"},
+	}).ComputedValuesForDataSource(target, ReplacementValue{}, schema)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+	first, _ := created.GetAttr("version").AsBigFloat().Int64()
+
+	// A simulated update carries the created value as prior state, and the
+	// counter moves forward by one.
+	updated, diags := NewValueGenerator(GenerateOptions{
+		IncrementingAttributes: []string{"version"},
+		Prior:                  created,
+	}).ComputedValuesForDataSource(target, ReplacementValue{}, schema)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+	if second, _ := updated.GetAttr("version").AsBigFloat().Int64(); second != first+1 {
+		t.Errorf("expected the version to increment from %d, got %d", first, second)
+	}
+}
+
+func TestValueGenerator_overrideFilter(t *testing.T) {
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"id": {
+				Type:     cty.String,
+				Computed: true,
+			},
+		},
+	}
+
+	target := cty.ObjectVal(map[string]cty.Value{
+		"id": cty.NullVal(cty.String),
+	})
+
+	with := ReplacementValue{
+		Value: cty.ObjectVal(map[string]cty.Value{
+			"id": cty.StringVal("supplied"),
+		}),
+	}
+
+	t.Run("rewrite", func(t *testing.T) {
+		generator := NewValueGenerator(GenerateOptions{
+			OverrideFilter: func(path cty.Path, supplied cty.Value) (cty.Value, bool) {
+				return cty.StringVal("rewritten-" + supplied.AsString()), true
+			},
+		})
+
+		value, diags := generator.ComputedValuesForDataSource(target, with, schema)
+		if diags.HasErrors() {
+			t.Fatalf("unexpected errors: %s", diags.Err())
+		}
+		if id := value.GetAttr("id").AsString(); id != "rewritten-supplied" {
+			t.Errorf("expected the rewritten override, got %q", id)
+		}
+	})
+
+	t.Run("veto", func(t *testing.T) {
+		generator := NewValueGenerator(GenerateOptions{
+			OverrideFilter: func(path cty.Path, supplied cty.Value) (cty.Value, bool) {
+				return cty.NilVal, false
+			},
+		})
+
+		testRand = rand.New(rand.NewSource(0))
+		defer func() {
+			testRand = nil
+		}()
+
+		value, diags := generator.ComputedValuesForDataSource(target, with, schema)
+		if diags.HasErrors() {
+			t.Fatalf("unexpected errors: %s", diags.Err())
+		}
+
+		// The vetoed override falls through to the usual random generation.
+		id := value.GetAttr("id").AsString()
+		if id == "supplied" {
+			t.Error("expected the vetoed override to be ignored")
+		}
+		if len(id) != 8 {
+			t.Errorf("expected a generated string, got %q", id)
+		}
+	})
+}
+
+func TestValueGenerator_base64Lengths(t *testing.T) {
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"certificate": {
+				Type:     cty.String,
+				Computed: true,
+			},
+		},
+	}
+
+	target := cty.ObjectVal(map[string]cty.Value{
+		"certificate": cty.NullVal(cty.String),
+	})
+
+	generate := func() string {
+		generator := NewValueGenerator(GenerateOptions{
+			Rand: NewPCGSource(11),
+			Base64Lengths: map[string]int{
+				"certificate": 32,
+			},
+		})
+
+		value, diags := generator.ComputedValuesForDataSource(target, ReplacementValue{}, schema)
+		if diags.HasErrors() {
+			t.Fatalf("unexpected errors: %s", diags.Err())
+		}
+		return value.GetAttr("certificate").AsString()
+	}
+
+	certificate := generate()
+	data, err := base64.StdEncoding.DecodeString(certificate)
+	if err != nil {
+		t.Fatalf("generated certificate %q is not valid base64: %s", certificate, err)
+	}
+	if len(data) != 32 {
+		t.Errorf("expected 32 bytes of data, got %d", len(data))
+	}
+
+	// And the output is deterministic under a fixed seed.
+	if second := generate(); second != certificate {
+		t.Errorf("expected deterministic output under a fixed seed, got %q and %q", certificate, second)
+	}
+}
+
+func TestValueGenerator_ambiguousOverrideKeys(t *testing.T) {
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"id": {
+				Type:     cty.String,
+				Computed: true,
+			},
+		},
+	}
+
+	target := cty.ObjectVal(map[string]cty.Value{
+		"id": cty.NullVal(cty.String),
+	})
+
+	// Two keys differing only in case both match the id attribute under
+	// case-insensitive matching.
+	with := ReplacementValue{
+		Value: cty.ObjectVal(map[string]cty.Value{
+			"Id": cty.StringVal("first"),
+			"iD": cty.StringVal("second"),
+		}),
+	}
+
+	generator := NewValueGenerator(GenerateOptions{
+		CaseInsensitiveOverrideKeys: true,
+	})
+
+	_, diags := generator.ComputedValuesForDataSource(target, with, schema)
+	if !diags.HasErrors() {
+		t.Fatalf("expected an error for the ambiguous keys, got %v", diags)
+	}
+
+	found := false
+	for _, diag := range diags {
+		if diag.Description().Summary == "Ambiguous override keys" {
+			found = true
+			if detail := diag.Description().Detail; !strings.Contains(detail, "Id, iD") {
+				t.Errorf("expected the detail to list the colliding keys, got: %s", detail)
+			}
+		}
+	}
+	if !found {
+		t.Error("expected an ambiguity diagnostic")
+	}
+
+	// Unambiguous loose matches are still accepted.
+	with = ReplacementValue{
+		Value: cty.ObjectVal(map[string]cty.Value{
+			"Id": cty.StringVal("first"),
+		}),
+	}
+	value, diags := generator.ComputedValuesForDataSource(target, with, schema)
+	if len(diags) != 0 {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+	if id := value.GetAttr("id").AsString(); id != "first" {
+		t.Errorf("expected the loose match to apply, got %q", id)
+	}
+}
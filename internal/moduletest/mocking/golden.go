@@ -0,0 +1,81 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package mocking
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+// updateGoldenEnvVar is the environment variable that makes
+// AssertMatchesGolden rewrite golden files instead of comparing against
+// them. We use an environment variable rather than registering a -update
+// flag ourselves so that importing this package never changes the flag set
+// of the test binary; callers with their own -update flag can just set
+// UpdateGoldenFiles from it.
+const updateGoldenEnvVar = "TF_MOCKING_UPDATE_GOLDEN"
+
+// UpdateGoldenFiles makes AssertMatchesGolden rewrite golden files with the
+// current output instead of comparing against them. It is also enabled by
+// setting the TF_MOCKING_UPDATE_GOLDEN environment variable to any
+// non-empty value.
+var UpdateGoldenFiles = false
+
+// TestingT is the subset of testing.TB that AssertMatchesGolden needs.
+// This package links into the terraform binary itself (via the mock
+// provider), so we accept the interface rather than importing the testing
+// package into production code; a *testing.T satisfies it directly.
+type TestingT interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+	Fatalf(format string, args ...interface{})
+}
+
+// AssertMatchesGolden compares the given value against the contents of a
+// golden file, failing the test with a readable diff if the two don't
+// match. Values are rendered with CanonicalValueString first, so the
+// comparison is insensitive to attribute ordering and set iteration order.
+//
+// When golden updates are enabled the file is rewritten with the current
+// rendering instead, and the assertion always passes.
+func AssertMatchesGolden(t TestingT, value cty.Value, goldenPath string) {
+	t.Helper()
+
+	if UpdateGoldenFiles || os.Getenv(updateGoldenEnvVar) != "" {
+		if err := os.WriteFile(goldenPath, []byte(renderGolden(value)), 0644); err != nil {
+			t.Fatalf("failed to update golden file %s: %s", goldenPath, err)
+		}
+		return
+	}
+
+	if diff, err := diffAgainstGolden(value, goldenPath); err != nil {
+		t.Fatalf("%s", err)
+	} else if diff != "" {
+		t.Errorf("%s", diff)
+	}
+}
+
+// diffAgainstGolden does the real comparison for AssertMatchesGolden,
+// returning a non-empty description when the value doesn't match the golden
+// file and an error when the file can't be read at all.
+func diffAgainstGolden(value cty.Value, goldenPath string) (string, error) {
+	expected, err := os.ReadFile(goldenPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read golden file %s: %w; set %s to create it", goldenPath, err, updateGoldenEnvVar)
+	}
+
+	actual := renderGolden(value)
+	if string(expected) != actual {
+		return fmt.Sprintf("value does not match golden file %s\nexpected:\n%sactual:\n%s", goldenPath, expected, actual), nil
+	}
+	return "", nil
+}
+
+// renderGolden renders a value the way golden files store it: canonical
+// form with a trailing newline.
+func renderGolden(value cty.Value) string {
+	return CanonicalValueString(value) + "\n"
+}
@@ -0,0 +1,59 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package mocking
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestAssertMatchesGolden(t *testing.T) {
+	golden := filepath.Join(t.TempDir(), "value.golden")
+
+	value := cty.ObjectVal(map[string]cty.Value{
+		"id":   cty.StringVal("ssnk9qhr"),
+		"tags": cty.ListValEmpty(cty.String),
+	})
+
+	// First write the golden file in update mode.
+	t.Setenv(updateGoldenEnvVar, "1")
+	AssertMatchesGolden(t, value, golden)
+
+	// Then the same value should match it in comparison mode, even with the
+	// attributes built in a different order.
+	t.Setenv(updateGoldenEnvVar, "")
+	same := cty.ObjectVal(map[string]cty.Value{
+		"tags": cty.ListValEmpty(cty.String),
+		"id":   cty.StringVal("ssnk9qhr"),
+	})
+	AssertMatchesGolden(t, same, golden)
+
+	// A different value reports a mismatch mentioning both renderings.
+	different := cty.ObjectVal(map[string]cty.Value{
+		"id":   cty.StringVal("changed"),
+		"tags": cty.ListValEmpty(cty.String),
+	})
+	diff, err := diffAgainstGolden(different, golden)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if diff == "" {
+		t.Fatalf("expected a mismatch for a different value")
+	}
+	for _, fragment := range []string{"ssnk9qhr", "changed"} {
+		if !strings.Contains(diff, fragment) {
+			t.Errorf("expected the diff to mention %q:\n%s", fragment, diff)
+		}
+	}
+
+	// A missing golden file is an error pointing at the update mechanism.
+	if _, err := diffAgainstGolden(value, filepath.Join(t.TempDir(), "missing.golden")); err == nil {
+		t.Errorf("expected an error for a missing golden file")
+	} else if !strings.Contains(err.Error(), updateGoldenEnvVar) {
+		t.Errorf("expected the error to mention %s, got: %s", updateGoldenEnvVar, err)
+	}
+}
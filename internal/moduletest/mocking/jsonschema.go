@@ -0,0 +1,79 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package mocking
+
+import (
+	"encoding/json"
+)
+
+// JSONSchema describes the subset of JSON Schema the generator understands
+// when asked to produce a conforming document via the JSONSchemas option.
+//
+// The subset is deliberately small: a type, an enum, and for objects the
+// required properties. That covers the common case of a provider validating
+// the rough shape of a JSON attribute without pulling a full JSON Schema
+// implementation into this package.
+type JSONSchema struct {
+	// Type names the JSON type of the document: "object", "array", "string",
+	// "number", "integer", "boolean", or "null".
+	Type string
+
+	// Enum lists the exact values the document may take. When non-empty the
+	// generator picks one of them at random and Type is ignored.
+	Enum []interface{}
+
+	// Properties describes the schema of each named property of an object.
+	Properties map[string]*JSONSchema
+
+	// Required lists the properties an object must include. The generated
+	// document is minimal, so these are also the only properties it gets.
+	Required []string
+}
+
+// generateJSONDocument builds a minimal document conforming to the given
+// schema and returns it serialised as JSON.
+func (g *ValueGenerator) generateJSONDocument(schema *JSONSchema) (string, error) {
+	data, err := json.Marshal(g.generateJSONValue(schema))
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// generateJSONValue builds the Go value that serialises into a minimal
+// document conforming to the given schema.
+func (g *ValueGenerator) generateJSONValue(schema *JSONSchema) interface{} {
+	if schema == nil {
+		// A required property with no schema of its own can hold anything,
+		// and null is the most minimal anything.
+		return nil
+	}
+
+	if len(schema.Enum) > 0 {
+		g.recordRNGCall()
+		return schema.Enum[g.rand().Intn(len(schema.Enum))]
+	}
+
+	switch schema.Type {
+	case "object":
+		properties := make(map[string]interface{}, len(schema.Required))
+		for _, name := range schema.Required {
+			properties[name] = g.generateJSONValue(schema.Properties[name])
+		}
+		return properties
+	case "array":
+		// An empty array is the minimal conforming array; we don't support
+		// minItems or similar constraints.
+		return []interface{}{}
+	case "string":
+		g.recordRNGCall()
+		return strFrom(g.rand(), 8)
+	case "number", "integer":
+		return 0
+	case "boolean":
+		return false
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,87 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package mocking
+
+import (
+	"encoding/json"
+	"math/rand"
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/hashicorp/terraform/internal/configs/configschema"
+)
+
+func TestValueGenerator_jsonSchemas(t *testing.T) {
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"config": {
+				Type:     cty.String,
+				Computed: true,
+			},
+		},
+	}
+
+	target := cty.ObjectVal(map[string]cty.Value{
+		"config": cty.NullVal(cty.String),
+	})
+
+	generate := func() string {
+		generator := NewValueGenerator(GenerateOptions{
+			JSONSchemas: map[string]*JSONSchema{
+				"config": {
+					Type:     "object",
+					Required: []string{"kind", "port"},
+					Properties: map[string]*JSONSchema{
+						"kind": {
+							Type: "string",
+							Enum: []interface{}{"basic", "advanced"},
+						},
+						"port": {
+							Type: "integer",
+						},
+						"comment": {
+							Type: "string",
+						},
+					},
+				},
+			},
+		})
+
+		value, diags := generator.ComputedValuesForDataSource(target, ReplacementValue{}, schema)
+		if diags.HasErrors() {
+			t.Fatalf("unexpected errors: %s", diags.Err())
+		}
+		return value.GetAttr("config").AsString()
+	}
+
+	testRand = rand.New(rand.NewSource(0))
+	defer func() {
+		testRand = nil
+	}()
+
+	document := generate()
+
+	var decoded map[string]interface{}
+	if err := json.Unmarshal([]byte(document), &decoded); err != nil {
+		t.Fatalf("generated config %q is not valid JSON: %s", document, err)
+	}
+
+	// The document is minimal: required properties and nothing else.
+	if len(decoded) != 2 {
+		t.Errorf("expected only the required properties, got %q", document)
+	}
+	if kind := decoded["kind"]; kind != "basic" && kind != "advanced" {
+		t.Errorf("expected kind to come from the enum, got %v", kind)
+	}
+	if port, ok := decoded["port"].(float64); !ok || port != 0 {
+		t.Errorf("expected a zero port, got %v", decoded["port"])
+	}
+
+	// And the output is deterministic under a fixed seed.
+	testRand = rand.New(rand.NewSource(0))
+	if second := generate(); second != document {
+		t.Errorf("expected deterministic output under a fixed seed, got %q and %q", document, second)
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package mocking
+
+import (
+	"sync"
+
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/hashicorp/terraform/internal/configs/configschema"
+	"github.com/hashicorp/terraform/internal/tfdiags"
+)
+
+// LazyValue defers a generation operation until its result is first needed.
+//
+// cty values are plain immutable data, so there is no way to build one whose
+// leaves materialise on access; the laziness here is per operation instead.
+// Construction does no work at all, the operation runs the first time Value
+// is called, and every later call returns the same cached value and
+// diagnostics. Callers mocking a large batch of resources where only some
+// values end up consumed can build all the LazyValues upfront and pay only
+// for the ones the test actually reads.
+//
+// A LazyValue is not safe for concurrent use, matching the generator that
+// backs it.
+type LazyValue struct {
+	once     sync.Once
+	generate func() (cty.Value, tfdiags.Diagnostics)
+	value    cty.Value
+	diags    tfdiags.Diagnostics
+}
+
+// Value runs the deferred operation on first call and returns its result,
+// cached thereafter.
+func (lazy *LazyValue) Value() (cty.Value, tfdiags.Diagnostics) {
+	lazy.once.Do(func() {
+		lazy.value, lazy.diags = lazy.generate()
+	})
+	return lazy.value, lazy.diags
+}
+
+// LazyPlanComputedValuesForResource is the deferred counterpart of
+// PlanComputedValuesForResource: nothing is generated until the returned
+// LazyValue is first read.
+func (g *ValueGenerator) LazyPlanComputedValuesForResource(original cty.Value, schema *configschema.Block) *LazyValue {
+	return &LazyValue{
+		generate: func() (cty.Value, tfdiags.Diagnostics) {
+			return g.PlanComputedValuesForResource(original, schema)
+		},
+	}
+}
+
+// LazyApplyComputedValuesForResource is the deferred counterpart of
+// ApplyComputedValuesForResource: nothing is generated until the returned
+// LazyValue is first read.
+func (g *ValueGenerator) LazyApplyComputedValuesForResource(original cty.Value, with ReplacementValue, schema *configschema.Block) *LazyValue {
+	return &LazyValue{
+		generate: func() (cty.Value, tfdiags.Diagnostics) {
+			return g.ApplyComputedValuesForResource(original, with, schema)
+		},
+	}
+}
+
+// LazyComputedValuesForDataSource is the deferred counterpart of
+// ComputedValuesForDataSource: nothing is generated until the returned
+// LazyValue is first read.
+func (g *ValueGenerator) LazyComputedValuesForDataSource(original cty.Value, with ReplacementValue, schema *configschema.Block) *LazyValue {
+	return &LazyValue{
+		generate: func() (cty.Value, tfdiags.Diagnostics) {
+			return g.ComputedValuesForDataSource(original, with, schema)
+		},
+	}
+}
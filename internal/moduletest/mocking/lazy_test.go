@@ -0,0 +1,69 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package mocking
+
+import (
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/hashicorp/terraform/internal/configs/configschema"
+)
+
+// countingSource counts how many draws it serves, so tests can tell whether
+// generation actually ran.
+type countingSource struct {
+	calls int
+}
+
+func (source *countingSource) Intn(n int) int {
+	source.calls++
+	return 0
+}
+
+func TestLazyValue(t *testing.T) {
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"id": {
+				Type:     cty.String,
+				Computed: true,
+			},
+		},
+	}
+
+	target := cty.ObjectVal(map[string]cty.Value{
+		"id": cty.NullVal(cty.String),
+	})
+
+	source := &countingSource{}
+	generator := NewValueGenerator(GenerateOptions{
+		Rand: source,
+	})
+
+	lazy := generator.LazyComputedValuesForDataSource(target, ReplacementValue{}, schema)
+	if source.calls != 0 {
+		t.Fatalf("expected no generation before the value is read, but saw %d draws", source.calls)
+	}
+
+	value, diags := lazy.Value()
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+	if id := value.GetAttr("id").AsString(); id != "aaaaaaaa" {
+		t.Errorf("wrong generated value: %q", id)
+	}
+	if source.calls == 0 {
+		t.Fatal("expected reading the value to run generation, but no draws happened")
+	}
+
+	// A second read returns the cached result without generating again.
+	drawn := source.calls
+	again, _ := lazy.Value()
+	if source.calls != drawn {
+		t.Errorf("expected the second read to be cached, but draws went from %d to %d", drawn, source.calls)
+	}
+	if !again.RawEquals(value) {
+		t.Errorf("cached value differs: %s vs %s", again.GoString(), value.GoString())
+	}
+}
@@ -0,0 +1,28 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package mocking
+
+import (
+	"github.com/zclconf/go-cty/cty"
+	ctymsgpack "github.com/zclconf/go-cty/cty/msgpack"
+
+	"github.com/hashicorp/terraform/internal/configs/configschema"
+)
+
+// MarshalGeneratedMsgpack encodes a generated value in cty's msgpack
+// encoding, against the implied type of the schema it was generated for.
+//
+// Msgpack is the compact binary-safe choice for storing or transmitting
+// mocked values: unlike the JSON encoding it can represent unknown values, so
+// the result of a plan-phase operation round-trips too. Decode with
+// UnmarshalGeneratedMsgpack and the same schema.
+func MarshalGeneratedMsgpack(value cty.Value, schema *configschema.Block) ([]byte, error) {
+	return ctymsgpack.Marshal(value, schema.ImpliedType())
+}
+
+// UnmarshalGeneratedMsgpack decodes a value previously encoded by
+// MarshalGeneratedMsgpack with the same schema.
+func UnmarshalGeneratedMsgpack(data []byte, schema *configschema.Block) (cty.Value, error) {
+	return ctymsgpack.Unmarshal(data, schema.ImpliedType())
+}
@@ -0,0 +1,84 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package mocking
+
+import (
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/hashicorp/terraform/internal/configs/configschema"
+)
+
+func TestMarshalGeneratedMsgpack_roundTrip(t *testing.T) {
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"id": {
+				Type:     cty.String,
+				Computed: true,
+			},
+			"tags": {
+				Type:     cty.Map(cty.String),
+				Optional: true,
+			},
+		},
+		BlockTypes: map[string]*configschema.NestedBlock{
+			"network": {
+				Nesting: configschema.NestingList,
+				Block: configschema.Block{
+					Attributes: map[string]*configschema.Attribute{
+						"cidr": {
+							Type:     cty.String,
+							Optional: true,
+						},
+						"gateway": {
+							Type:     cty.String,
+							Computed: true,
+						},
+					},
+				},
+			},
+		},
+	}
+
+	target := cty.ObjectVal(map[string]cty.Value{
+		"id": cty.NullVal(cty.String),
+		"tags": cty.MapVal(map[string]cty.Value{
+			"env": cty.StringVal("test"),
+		}),
+		"network": cty.ListVal([]cty.Value{
+			cty.ObjectVal(map[string]cty.Value{
+				"cidr":    cty.StringVal("10.0.0.0/16"),
+				"gateway": cty.NullVal(cty.String),
+			}),
+		}),
+	})
+
+	// A plan-phase value carries unknowns, an apply-phase one is fully
+	// known; both must round-trip.
+	planned, diags := PlanComputedValuesForResource(target, schema)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+	applied, diags := ComputedValuesForDataSource(target, ReplacementValue{}, schema)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+
+	for name, value := range map[string]cty.Value{"planned": planned, "applied": applied} {
+		data, err := MarshalGeneratedMsgpack(value, schema)
+		if err != nil {
+			t.Fatalf("failed to marshal %s value: %s", name, err)
+		}
+
+		decoded, err := UnmarshalGeneratedMsgpack(data, schema)
+		if err != nil {
+			t.Fatalf("failed to unmarshal %s value: %s", name, err)
+		}
+
+		if !decoded.RawEquals(value) {
+			t.Errorf("%s value did not round-trip:\nbefore: %s\nafter:  %s", name, value.GoString(), decoded.GoString())
+		}
+	}
+}
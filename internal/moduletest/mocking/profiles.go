@@ -0,0 +1,60 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package mocking
+
+import (
+	"fmt"
+)
+
+// The built-in generation profiles. See the Profile option.
+const (
+	// ProfileAWSLike generates identifiers shaped like AWS ones: "id"
+	// attributes get instance-style identifiers and "arn" attributes get
+	// ARN-shaped strings.
+	ProfileAWSLike = "aws-like"
+
+	// ProfileGCPLike generates identifiers shaped like GCP ones: "id"
+	// attributes get project-scoped resource paths and "self_link"
+	// attributes get googleapis.com URLs.
+	ProfileGCPLike = "gcp-like"
+)
+
+// knownProfile returns true if the given name is one of the built-in
+// generation profiles.
+func knownProfile(name string) bool {
+	switch name {
+	case ProfileAWSLike, ProfileGCPLike:
+		return true
+	default:
+		return false
+	}
+}
+
+// profileStringForName generates a string for the attribute with the given
+// name following the named profile, returning false if the profile has no
+// opinion about the name. Attributes the profile doesn't recognise fall back
+// to the usual generators.
+func profileStringForName(profileName string, source RandSource, name string) (string, bool) {
+	switch profileName {
+	case ProfileAWSLike:
+		switch {
+		case nameSuggestsFormat(name, "arn"):
+			return fmt.Sprintf("arn:aws:mock:us-east-1:123456789012:%s", strFrom(source, 12)), true
+		case nameSuggestsFormat(name, "id"):
+			return fmt.Sprintf("i-%s", strFrom(source, 17)), true
+		case nameSuggestsFormat(name, "name"):
+			return fmt.Sprintf("tf-mock-%s", strFrom(source, 8)), true
+		}
+	case ProfileGCPLike:
+		switch {
+		case nameSuggestsFormat(name, "self_link"):
+			return fmt.Sprintf("https://www.googleapis.com/mock/v1/projects/%s/resources/%s", strFrom(source, 8), strFrom(source, 8)), true
+		case nameSuggestsFormat(name, "id"):
+			return fmt.Sprintf("projects/%s/resources/%s", strFrom(source, 8), strFrom(source, 8)), true
+		case nameSuggestsFormat(name, "name"):
+			return fmt.Sprintf("tf-mock-%s", strFrom(source, 8)), true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,112 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package mocking
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/hashicorp/terraform/internal/configs/configschema"
+)
+
+func TestValueGenerator_profiles(t *testing.T) {
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"id": {
+				Type:     cty.String,
+				Computed: true,
+			},
+			"arn": {
+				Type:     cty.String,
+				Computed: true,
+			},
+			"self_link": {
+				Type:     cty.String,
+				Computed: true,
+			},
+			"description": {
+				Type:     cty.String,
+				Computed: true,
+			},
+		},
+	}
+
+	target := cty.ObjectVal(map[string]cty.Value{
+		"id":          cty.NullVal(cty.String),
+		"arn":         cty.NullVal(cty.String),
+		"self_link":   cty.NullVal(cty.String),
+		"description": cty.NullVal(cty.String),
+	})
+
+	tcs := map[string]struct {
+		profile  string
+		patterns map[string]string
+	}{
+		"aws-like": {
+			profile: ProfileAWSLike,
+			patterns: map[string]string{
+				"id":          `^i-[a-z0-9]{17}$`,
+				"arn":         `^arn:aws:mock:us-east-1:123456789012:[a-z0-9]{12}$`,
+				"description": `^[a-z0-9]{8}$`,
+			},
+		},
+		"gcp-like": {
+			profile: ProfileGCPLike,
+			patterns: map[string]string{
+				"id":          `^projects/[a-z0-9]{8}/resources/[a-z0-9]{8}$`,
+				"self_link":   `^https://www\.googleapis\.com/mock/v1/projects/[a-z0-9]{8}/resources/[a-z0-9]{8}$`,
+				"description": `^[a-z0-9]{8}$`,
+			},
+		},
+	}
+
+	for name, tc := range tcs {
+		t.Run(name, func(t *testing.T) {
+			generator := NewValueGenerator(GenerateOptions{
+				Profile: tc.profile,
+			})
+
+			value, diags := generator.ComputedValuesForDataSource(target, ReplacementValue{}, schema)
+			if diags.HasErrors() {
+				t.Fatalf("unexpected errors: %s", diags.Err())
+			}
+
+			for attr, pattern := range tc.patterns {
+				generated := value.GetAttr(attr).AsString()
+				if !regexp.MustCompile(pattern).MatchString(generated) {
+					t.Errorf("generated value %q for %q doesn't match %s", generated, attr, pattern)
+				}
+			}
+		})
+	}
+}
+
+func TestValueGenerator_unknownProfile(t *testing.T) {
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"id": {
+				Type:     cty.String,
+				Computed: true,
+			},
+		},
+	}
+
+	target := cty.ObjectVal(map[string]cty.Value{
+		"id": cty.NullVal(cty.String),
+	})
+
+	generator := NewValueGenerator(GenerateOptions{
+		Profile: "azure-like",
+	})
+
+	_, diags := generator.ComputedValuesForDataSource(target, ReplacementValue{}, schema)
+	if !diags.HasErrors() {
+		t.Fatal("expected an unknown profile error, but got none")
+	}
+	if summary := diags[0].Description().Summary; summary != "Unknown generation profile" {
+		t.Errorf("wrong summary: %s", summary)
+	}
+}
@@ -0,0 +1,131 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package mocking
+
+import (
+	"time"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+// algorithmVersion identifies the current version of the value generation
+// algorithm. Bump this whenever a change alters the values generated for an
+// existing schema under a fixed seed, so reports can distinguish values
+// produced by different algorithms.
+const algorithmVersion = 1
+
+// GenerateReport collects optional metadata about a single generation
+// operation.
+//
+// Callers opt in by pointing the Report field of GenerateOptions at a
+// GenerateReport; the generator then fills the report in as it works. The
+// report never affects the generated value itself, it exists purely so
+// tooling built on top of this package can cross-check what the generator
+// did.
+type GenerateReport struct {
+	// ImpliedType is the value type implied by the schema the operation ran
+	// against, as computed by the schema's own ImpliedType method. Optional
+	// and computed attributes are part of this type just like required ones,
+	// so tooling can verify the generated value conforms to it.
+	ImpliedType cty.Type
+
+	// AlgorithmVersions records, for each attribute path that received a
+	// generated value, the version of the generation algorithm that
+	// produced it. Attributes filled from caller-supplied overrides are
+	// not recorded since no algorithm was involved. Tooling that stores
+	// generated values long-term can use this to tell whether a stored
+	// value came from an older algorithm.
+	AlgorithmVersions map[string]int
+
+	// ResolvedOverrides records, for each attribute path, the override value
+	// that was effectively applied there after the replacement lookup rules
+	// ran. This can differ substantially from the raw override object the
+	// caller supplied: a single override for an attribute inside a set
+	// block, for example, is broadcast to every element of the set. Paths
+	// are rendered without index steps, matching how overrides are looked
+	// up.
+	ResolvedOverrides map[string]cty.Value
+
+	// SetOrderings records, for each set within the final value (keyed by
+	// its path as rendered by fmtPath), the canonical rendering of its
+	// elements in the order CanonicalValueString prints them. Golden tests
+	// comparing against rendered output can use this to align their own
+	// expectations with the canonical element order.
+	SetOrderings map[string][]string
+
+	// AbsentAttributes lists, in lexical order, the top-level schema
+	// attributes and block types the target object has no key for at all.
+	// A fixture written against an older schema drifts out of sync this
+	// way, so authors can use this to keep fixtures current. Missing keys
+	// are not errors; generation just treats them as null.
+	AbsentAttributes []string
+
+	// GeneratedCount, SuppliedCount, and PreservedCount summarise where the
+	// values of computed attributes came from: invented by the generator,
+	// taken from a caller-supplied override, or already present in the
+	// target and left untouched. Test runners can log these three numbers
+	// as a quick picture of how much of a mocked value was real data.
+	GeneratedCount int
+	SuppliedCount  int
+	PreservedCount int
+
+	// AppliedMarks records, for each attribute path the generator marked,
+	// the marks it applied there, each rendered with %#v (so
+	// "marks.Sensitive", "marks.Ephemeral", and so on). Tests verifying mark
+	// behaviour can read this instead of unmarking and inspecting the value
+	// themselves. Marks the target already carried are not recorded; only
+	// ones the generator added are.
+	AppliedMarks map[string][]string
+
+	// GeneratedStringBytes is the cumulative byte length of every string
+	// value the generator invented, for callers auditing how much data a
+	// mocked value carries. Strings taken from overrides, pools, or the
+	// target don't count; only invented ones do.
+	GeneratedStringBytes int
+
+	// EffectiveOptions is a copy of the generation options the operation ran
+	// with, after defaults were resolved: MaxDepth holds the real limit, and
+	// Rand and Clock hold the sources actually consulted rather than nil.
+	// Feeding these options to a fresh generator replays the run exactly,
+	// provided the random source is reseeded the same way. The copy's own
+	// Report field is nil so the record doesn't refer back to itself.
+	EffectiveOptions *GenerateOptions
+
+	// RNGCalls counts how many random values the generator drew from its
+	// random source during the operation. Callers comparing runs under a
+	// fixed seed can use this to detect when a schema change has altered
+	// the draw sequence, which would reorder every subsequent value.
+	RNGCalls int
+}
+
+// GenerateMetrics collects profiling information about a single generation
+// operation, for callers diagnosing slow generation over very large schemas.
+//
+// As with GenerateReport, callers opt in by pointing the Metrics field of
+// GenerateOptions at a GenerateMetrics value.
+type GenerateMetrics struct {
+	// Timings records the cumulative time spent processing values beneath
+	// each top-level attribute or block type of the schema, keyed by the
+	// attribute or block type name. Expensive subtrees in a huge schema show
+	// up here as outsized durations.
+	Timings map[string]time.Duration
+}
+
+// record adds the time elapsed since start to the timing bucket for the
+// top-level step of the given path.
+func (metrics *GenerateMetrics) record(path cty.Path, start time.Time) {
+	if len(path) == 0 {
+		return
+	}
+
+	step, ok := path[0].(cty.GetAttrStep)
+	if !ok {
+		return
+	}
+
+	if metrics.Timings == nil {
+		metrics.Timings = make(map[string]time.Duration)
+	}
+	metrics.Timings[step.Name] += time.Since(start)
+}
@@ -0,0 +1,582 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package mocking
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/hashicorp/terraform/internal/configs/configschema"
+)
+
+func TestGenerateReport_impliedType(t *testing.T) {
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"id": {
+				Type:     cty.String,
+				Computed: true,
+			},
+			"name": {
+				Type:     cty.String,
+				Optional: true,
+			},
+			"nested": {
+				NestedType: &configschema.Object{
+					Attributes: map[string]*configschema.Attribute{
+						"value": {
+							Type:     cty.String,
+							Optional: true,
+						},
+					},
+					Nesting: configschema.NestingSingle,
+				},
+			},
+		},
+	}
+
+	target := cty.ObjectVal(map[string]cty.Value{
+		"id":   cty.NullVal(cty.String),
+		"name": cty.NullVal(cty.String),
+		"nested": cty.NullVal(cty.Object(map[string]cty.Type{
+			"value": cty.String,
+		})),
+	})
+
+	testRand = rand.New(rand.NewSource(0))
+	defer func() {
+		testRand = nil
+	}()
+
+	var report GenerateReport
+	generator := NewValueGenerator(GenerateOptions{
+		Report: &report,
+	})
+
+	_, diags := generator.ComputedValuesForDataSource(target, ReplacementValue{}, schema)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+
+	expected := cty.Object(map[string]cty.Type{
+		"id":   cty.String,
+		"name": cty.String,
+		"nested": cty.Object(map[string]cty.Type{
+			"value": cty.String,
+		}),
+	})
+
+	if !report.ImpliedType.Equals(expected) {
+		t.Errorf("reported implied type does not match\nexpected: %s\nactual:   %s", expected.GoString(), report.ImpliedType.GoString())
+	}
+
+	if !report.ImpliedType.Equals(schema.ImpliedType()) {
+		t.Errorf("reported implied type does not match the schema's own implied type")
+	}
+}
+
+func TestGenerateMetrics_timings(t *testing.T) {
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"id": {
+				Type:     cty.String,
+				Computed: true,
+			},
+			"value": {
+				Type: cty.String,
+			},
+		},
+		BlockTypes: map[string]*configschema.NestedBlock{
+			"block": {
+				Block:   computedBlock,
+				Nesting: configschema.NestingList,
+			},
+		},
+	}
+
+	target := cty.ObjectVal(map[string]cty.Value{
+		"id":    cty.NullVal(cty.String),
+		"value": cty.StringVal("Hello, world!"),
+		"block": cty.ListVal([]cty.Value{
+			cty.ObjectVal(map[string]cty.Value{
+				"id":    cty.NullVal(cty.String),
+				"value": cty.StringVal("one"),
+			}),
+		}),
+	})
+
+	testRand = rand.New(rand.NewSource(0))
+	defer func() {
+		testRand = nil
+	}()
+
+	var metrics GenerateMetrics
+	generator := NewValueGenerator(GenerateOptions{
+		Metrics: &metrics,
+	})
+
+	_, diags := generator.ComputedValuesForDataSource(target, ReplacementValue{}, schema)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+
+	for _, name := range []string{"id", "value", "block"} {
+		if _, ok := metrics.Timings[name]; !ok {
+			t.Errorf("expected a timing entry for top-level attribute %q, but there was none", name)
+		}
+	}
+	if len(metrics.Timings) != 3 {
+		t.Errorf("expected exactly three timing entries, got %d", len(metrics.Timings))
+	}
+}
+
+func TestGenerateReport_rngCalls(t *testing.T) {
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"id": {
+				Type:     cty.String,
+				Computed: true,
+			},
+			"arn": {
+				Type:     cty.String,
+				Computed: true,
+			},
+			"name": {
+				Type:     cty.String,
+				Computed: true,
+			},
+			"enabled": {
+				Type:     cty.Bool,
+				Computed: true,
+			},
+		},
+	}
+
+	target := cty.ObjectVal(map[string]cty.Value{
+		"id":      cty.NullVal(cty.String),
+		"arn":     cty.NullVal(cty.String),
+		"name":    cty.NullVal(cty.String),
+		"enabled": cty.NullVal(cty.Bool),
+	})
+
+	testRand = rand.New(rand.NewSource(0))
+	defer func() {
+		testRand = nil
+	}()
+
+	var report GenerateReport
+	generator := NewValueGenerator(GenerateOptions{
+		Report: &report,
+	})
+
+	_, diags := generator.ComputedValuesForDataSource(target, ReplacementValue{}, schema)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+
+	// One call per generated string, plus one for the generated boolean.
+	if report.RNGCalls != 4 {
+		t.Errorf("expected 4 RNG calls, got %d", report.RNGCalls)
+	}
+}
+
+func TestGenerateReport_algorithmVersions(t *testing.T) {
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"id": {
+				Type:     cty.String,
+				Computed: true,
+			},
+			"arn": {
+				Type:     cty.String,
+				Computed: true,
+			},
+		},
+	}
+
+	target := cty.ObjectVal(map[string]cty.Value{
+		"id":  cty.NullVal(cty.String),
+		"arn": cty.NullVal(cty.String),
+	})
+
+	// The arn comes from an override, so only the id should be attributed
+	// to the generation algorithm.
+	with := ReplacementValue{
+		Value: cty.ObjectVal(map[string]cty.Value{
+			"arn": cty.StringVal("arn:aws:mock"),
+		}),
+	}
+
+	testRand = rand.New(rand.NewSource(0))
+	defer func() {
+		testRand = nil
+	}()
+
+	var report GenerateReport
+	generator := NewValueGenerator(GenerateOptions{
+		Report: &report,
+	})
+
+	_, diags := generator.ComputedValuesForDataSource(target, with, schema)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+
+	if version, ok := report.AlgorithmVersions["id"]; !ok || version != algorithmVersion {
+		t.Errorf("expected id to be recorded with version %d, got %d (recorded %t)", algorithmVersion, version, ok)
+	}
+	if _, ok := report.AlgorithmVersions["arn"]; ok {
+		t.Errorf("did not expect a version entry for the overridden arn attribute")
+	}
+}
+
+func TestGenerateReport_resolvedOverrides(t *testing.T) {
+	schema := &configschema.Block{
+		BlockTypes: map[string]*configschema.NestedBlock{
+			"block": {
+				Block:   computedBlock,
+				Nesting: configschema.NestingSet,
+			},
+		},
+	}
+
+	target := cty.ObjectVal(map[string]cty.Value{
+		"block": cty.SetVal([]cty.Value{
+			cty.ObjectVal(map[string]cty.Value{
+				"id":    cty.NullVal(cty.String),
+				"value": cty.StringVal("one"),
+			}),
+			cty.ObjectVal(map[string]cty.Value{
+				"id":    cty.NullVal(cty.String),
+				"value": cty.StringVal("two"),
+			}),
+		}),
+	})
+
+	// A single override for the nested id is broadcast across every element
+	// of the set.
+	with := ReplacementValue{
+		Value: cty.ObjectVal(map[string]cty.Value{
+			"block": cty.ObjectVal(map[string]cty.Value{
+				"id": cty.StringVal("broadcast"),
+			}),
+		}),
+	}
+
+	testRand = rand.New(rand.NewSource(0))
+	defer func() {
+		testRand = nil
+	}()
+
+	var report GenerateReport
+	generator := NewValueGenerator(GenerateOptions{
+		Report: &report,
+	})
+
+	_, diags := generator.ComputedValuesForDataSource(target, with, schema)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+
+	// The resolved override is recorded against the attribute inside the
+	// set, not against the raw object the caller supplied.
+	resolved, ok := report.ResolvedOverrides["block.id"]
+	if !ok {
+		t.Fatalf("expected a resolved override for block.id, got %v", report.ResolvedOverrides)
+	}
+	if resolved.Equals(cty.StringVal("broadcast")).False() {
+		t.Errorf("unexpected resolved override: %s", resolved.GoString())
+	}
+	if _, ok := report.ResolvedOverrides["block"]; ok {
+		t.Errorf("did not expect the raw block override to be recorded as applied")
+	}
+}
+
+func TestGenerateReport_setOrderings(t *testing.T) {
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"zones": {
+				Type:     cty.Set(cty.String),
+				Required: true,
+			},
+		},
+	}
+
+	target := cty.ObjectVal(map[string]cty.Value{
+		"zones": cty.SetVal([]cty.Value{
+			cty.StringVal("us-east-1b"),
+			cty.StringVal("us-east-1a"),
+		}),
+	})
+
+	var report GenerateReport
+	generator := NewValueGenerator(GenerateOptions{
+		Report: &report,
+	})
+
+	value, diags := generator.ComputedValuesForDataSource(target, ReplacementValue{}, schema)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+
+	ordering, ok := report.SetOrderings["zones"]
+	if !ok {
+		t.Fatalf("expected an ordering for zones, got %v", report.SetOrderings)
+	}
+	if len(ordering) != 2 {
+		t.Fatalf("expected two elements in the ordering, got %d", len(ordering))
+	}
+
+	// The reported order is exactly the order the canonical rendering
+	// prints the elements in.
+	rendered := CanonicalValueString(value.GetAttr("zones"))
+	expected := "set(" + ordering[0] + ", " + ordering[1] + ")"
+	if rendered != expected {
+		t.Errorf("reported ordering does not match the rendering\nreported: %s\nrendered: %s", expected, rendered)
+	}
+}
+
+func TestGenerateReport_counts(t *testing.T) {
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"id": {
+				Type:     cty.String,
+				Computed: true,
+			},
+			"arn": {
+				Type:     cty.String,
+				Computed: true,
+			},
+			"status": {
+				Type:     cty.String,
+				Computed: true,
+			},
+		},
+	}
+
+	// One attribute is already known in the target, one is covered by an
+	// override, and one is left for the generator to invent.
+	target := cty.ObjectVal(map[string]cty.Value{
+		"id":     cty.NullVal(cty.String),
+		"arn":    cty.NullVal(cty.String),
+		"status": cty.StringVal("ready"),
+	})
+
+	with := ReplacementValue{
+		Value: cty.ObjectVal(map[string]cty.Value{
+			"arn": cty.StringVal("arn:aws:mock"),
+		}),
+	}
+
+	report := &GenerateReport{}
+	generator := NewValueGenerator(GenerateOptions{
+		Report: report,
+	})
+
+	_, diags := generator.ComputedValuesForDataSource(target, with, schema)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+
+	if report.GeneratedCount != 1 {
+		t.Errorf("expected 1 generated attribute, got %d", report.GeneratedCount)
+	}
+	if report.SuppliedCount != 1 {
+		t.Errorf("expected 1 supplied attribute, got %d", report.SuppliedCount)
+	}
+	if report.PreservedCount != 1 {
+		t.Errorf("expected 1 preserved attribute, got %d", report.PreservedCount)
+	}
+}
+
+func TestGenerateReport_absentAttributes(t *testing.T) {
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"id": {
+				Type:     cty.String,
+				Computed: true,
+			},
+			"arn": {
+				Type:     cty.String,
+				Computed: true,
+			},
+		},
+		BlockTypes: map[string]*configschema.NestedBlock{
+			"network": {
+				Nesting: configschema.NestingSingle,
+				Block: configschema.Block{
+					Attributes: map[string]*configschema.Attribute{
+						"cidr": {
+							Type:     cty.String,
+							Optional: true,
+						},
+					},
+				},
+			},
+		},
+	}
+
+	// The fixture only ever mentions id, so both arn and the network block
+	// count as absent.
+	target := cty.ObjectVal(map[string]cty.Value{
+		"id": cty.NullVal(cty.String),
+	})
+
+	report := &GenerateReport{}
+	generator := NewValueGenerator(GenerateOptions{
+		Report: report,
+	})
+
+	_, diags := generator.ComputedValuesForDataSource(target, ReplacementValue{}, schema)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+
+	expected := []string{"arn", "network"}
+	if len(report.AbsentAttributes) != len(expected) {
+		t.Fatalf("expected absent attributes %v, got %v", expected, report.AbsentAttributes)
+	}
+	for ix := range expected {
+		if report.AbsentAttributes[ix] != expected[ix] {
+			t.Fatalf("expected absent attributes %v, got %v", expected, report.AbsentAttributes)
+		}
+	}
+}
+
+func TestGenerateReport_generatedStringBytes(t *testing.T) {
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"id": {
+				Type:     cty.String,
+				Computed: true,
+			},
+			"token": {
+				Type:     cty.String,
+				Computed: true,
+			},
+			"count": {
+				Type:     cty.Number,
+				Computed: true,
+			},
+		},
+	}
+
+	target := cty.ObjectVal(map[string]cty.Value{
+		"id":    cty.NullVal(cty.String),
+		"token": cty.NullVal(cty.String),
+		"count": cty.NullVal(cty.Number),
+	})
+
+	report := &GenerateReport{}
+	generator := NewValueGenerator(GenerateOptions{
+		Report: report,
+		StringLengths: map[string]int{
+			"token": 32,
+		},
+	})
+
+	_, diags := generator.ComputedValuesForDataSource(target, ReplacementValue{}, schema)
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+
+	// An 8 byte default id plus the 32 bytes requested for token; numbers
+	// contribute nothing.
+	if report.GeneratedStringBytes != 40 {
+		t.Errorf("expected 40 generated string bytes, got %d", report.GeneratedStringBytes)
+	}
+}
+
+func TestGenerateReport_appliedMarks(t *testing.T) {
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"secret": {
+				Type:     cty.String,
+				Computed: true,
+			},
+			"id": {
+				Type:     cty.String,
+				Computed: true,
+			},
+		},
+	}
+
+	target := cty.ObjectVal(map[string]cty.Value{
+		"secret": cty.NullVal(cty.String),
+		"id":     cty.NullVal(cty.String),
+	})
+
+	report := &GenerateReport{}
+	generator := NewValueGenerator(GenerateOptions{
+		Report: report,
+		SensitivePredicates: map[string]func(cty.Value) bool{
+			"secret": func(cty.Value) bool { return true },
+		},
+	})
+
+	testRand = rand.New(rand.NewSource(0))
+	defer func() {
+		testRand = nil
+	}()
+
+	if _, diags := generator.ComputedValuesForDataSource(target, ReplacementValue{}, schema); diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+
+	if len(report.AppliedMarks) != 1 {
+		t.Fatalf("expected marks at exactly one path, got %v", report.AppliedMarks)
+	}
+	if applied := report.AppliedMarks["secret"]; len(applied) != 1 || applied[0] != "marks.Sensitive" {
+		t.Errorf("expected the sensitive mark to be reported for secret, got %v", applied)
+	}
+}
+
+func TestGenerateReport_effectiveOptions(t *testing.T) {
+	schema := &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"id": {
+				Type:     cty.String,
+				Computed: true,
+			},
+		},
+	}
+
+	target := cty.ObjectVal(map[string]cty.Value{
+		"id": cty.NullVal(cty.String),
+	})
+
+	report := &GenerateReport{}
+	generator := NewValueGenerator(GenerateOptions{
+		Report: report,
+	})
+
+	testRand = rand.New(rand.NewSource(0))
+	defer func() {
+		testRand = nil
+	}()
+
+	if _, diags := generator.ComputedValuesForDataSource(target, ReplacementValue{}, schema); diags.HasErrors() {
+		t.Fatalf("unexpected errors: %s", diags.Err())
+	}
+
+	effective := report.EffectiveOptions
+	if effective == nil {
+		t.Fatal("expected the report to record the effective options")
+	}
+
+	// Defaults are resolved in the copy.
+	if effective.MaxDepth != defaultMaxDepth {
+		t.Errorf("expected the default depth limit, got %d", effective.MaxDepth)
+	}
+	if effective.Rand == nil {
+		t.Error("expected the resolved random source")
+	}
+	if effective.Clock == nil {
+		t.Error("expected the resolved clock")
+	}
+	if effective.Report != nil {
+		t.Error("expected the copy's Report field to be nil")
+	}
+}
@@ -0,0 +1,57 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package mocking
+
+import (
+	"fmt"
+)
+
+// NewPCGSource returns a RandSource backed by the PCG-XSH-RR generator,
+// seeded with the given value.
+//
+// PCG has considerably better statistical properties than the additive
+// lagged Fibonacci generator behind math/rand, which matters to callers
+// using generated values for fuzzing rather than plain mocking. It is not
+// the default because switching the algorithm would change every value
+// generated under an existing seed, breaking golden outputs; plug it in
+// via the Rand generation option when distribution quality matters.
+func NewPCGSource(seed uint64) RandSource {
+	source := &pcgSource{
+		increment: 1442695040888963407,
+	}
+
+	// The standard PCG seeding sequence: step once from zero, add the
+	// seed, and step again so the first output already depends on it.
+	source.next()
+	source.state += seed
+	source.next()
+	return source
+}
+
+// pcgSource implements RandSource with the PCG-XSH-RR algorithm: a 64-bit
+// linear congruential state reduced to 32-bit outputs through an
+// xorshift-and-rotate output function.
+type pcgSource struct {
+	state     uint64
+	increment uint64
+}
+
+func (source *pcgSource) Intn(n int) int {
+	if n <= 0 {
+		panic(fmt.Errorf("invalid argument to Intn: %d", n))
+	}
+
+	// The tiny modulo bias here is irrelevant for generating mock values,
+	// so we don't bother with rejection sampling.
+	return int(source.next() % uint32(n))
+}
+
+func (source *pcgSource) next() uint32 {
+	old := source.state
+	source.state = old*6364136223846793005 + source.increment
+
+	xorshifted := uint32(((old >> 18) ^ old) >> 27)
+	rotation := uint32(old >> 59)
+	return (xorshifted >> rotation) | (xorshifted << ((-rotation) & 31))
+}
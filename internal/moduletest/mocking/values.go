@@ -6,10 +6,10 @@ package mocking
 import (
 	"fmt"
 	"math/rand"
+	"strings"
 
 	"github.com/hashicorp/hcl/v2"
 	"github.com/zclconf/go-cty/cty"
-	"github.com/zclconf/go-cty/cty/convert"
 
 	"github.com/hashicorp/terraform/internal/configs/configschema"
 	"github.com/hashicorp/terraform/internal/tfdiags"
@@ -31,7 +31,7 @@ var (
 // This method basically simulates the behaviour of a plan request in a real
 // provider.
 func PlanComputedValuesForResource(original cty.Value, schema *configschema.Block) (cty.Value, tfdiags.Diagnostics) {
-	return populateComputedValues(original, ReplacementValue{}, schema, isNull, makeUnknown)
+	return NewValueGenerator(GenerateOptions{}).PlanComputedValuesForResource(original, schema)
 }
 
 // ApplyComputedValuesForResource accepts a target value, and populates it
@@ -42,7 +42,7 @@ func PlanComputedValuesForResource(original cty.Value, schema *configschema.Bloc
 // This method basically simulates the behaviour of an apply request in a real
 // provider.
 func ApplyComputedValuesForResource(original cty.Value, with ReplacementValue, schema *configschema.Block) (cty.Value, tfdiags.Diagnostics) {
-	return populateComputedValues(original, with, schema, isUnknown, with.makeKnown)
+	return NewValueGenerator(GenerateOptions{}).ApplyComputedValuesForResource(original, with, schema)
 }
 
 // ComputedValuesForDataSource accepts a target value, and populates it either
@@ -56,94 +56,13 @@ func ApplyComputedValuesForResource(original cty.Value, with ReplacementValue, s
 // This method basically simulates the behaviour of a get data source request
 // in a real provider.
 func ComputedValuesForDataSource(original cty.Value, with ReplacementValue, schema *configschema.Block) (cty.Value, tfdiags.Diagnostics) {
-	return populateComputedValues(original, with, schema, isNull, with.makeKnown)
+	return NewValueGenerator(GenerateOptions{}).ComputedValuesForDataSource(original, with, schema)
 }
 
 type processValue func(value cty.Value) bool
 
 type populateValue func(value cty.Value, with cty.Value, path cty.Path) (cty.Value, tfdiags.Diagnostics)
 
-func populateComputedValues(target cty.Value, with ReplacementValue, schema *configschema.Block, processValue processValue, populateValue populateValue) (cty.Value, tfdiags.Diagnostics) {
-	var diags tfdiags.Diagnostics
-
-	if !with.validate() {
-		// This is actually a user error, it means the user wrote something like
-		// `values = "not an object"` when defining the replacement values for
-		// this in the mock or test file. We should have caught this earlier in
-		// the validation, but we want this function to be robust and not panic
-		// so we'll check again just in case.
-		diags = diags.Append(&hcl.Diagnostic{
-			Severity: hcl.DiagError,
-			Summary:  "Invalid replacement value",
-			Detail:   fmt.Sprintf("The requested replacement value must be an object type, but was %s.", with.Value.Type().FriendlyName()),
-			Subject:  with.Range.Ptr(),
-		})
-	}
-
-	// We're going to search for any elements within the target value that meet
-	// the joint criteria of being computed and whatever processValue is
-	// checking.
-	//
-	// We'll then replace anything that meets the criteria with the output of
-	// populateValue.
-	//
-	// This transform should be robust (in that it should never fail), it'll
-	// populate the external diags variable with any values it should have
-	// replaced but couldn't and just return the original value.
-	value, err := cty.Transform(target, func(path cty.Path, target cty.Value) (cty.Value, error) {
-
-		// Get the attribute for the current target.
-		attribute := schema.AttributeByPath(path)
-
-		if attribute == nil {
-			// Then this is an intermediate path which does not represent an
-			// attribute, and it cannot be computed.
-			return target, nil
-		}
-
-		// Now, we check if we should be replacing this value with something.
-		if attribute.Computed && processValue(target) {
-
-			// Get the value we should be replacing target with.
-			replacement, replacementDiags := with.getReplacementSafe(path)
-			diags = diags.Append(replacementDiags)
-
-			// Upstream code (in node_resource_abstract_instance.go) expects
-			// us to return a valid object (even if we have errors). That means
-			// no unknown values, no cty.NilVals, etc. So, we're going to go
-			// ahead and call populateValue with whatever getReplacementSafe
-			// gave us. getReplacementSafe is robust, so even in an error it
-			// should have given us something we can use in populateValue.
-
-			// Now get the replacement value. This function should be robust in
-			// that it may return diagnostics explaining why it couldn't replace
-			// the value, but it'll still return a value for us to use.
-			value, valueDiags := populateValue(target, replacement, path)
-			diags = diags.Append(valueDiags)
-
-			// We always return a valid value, the diags are attached to the
-			// global diags outside the nested function.
-			return value, nil
-		}
-
-		// If we don't need to replace this value, then just return it
-		// untouched.
-		return target, nil
-	})
-	if err != nil {
-		// This shouldn't actually happen - we never return an error from inside
-		// the transform function. But, just in case:
-		diags = diags.Append(&hcl.Diagnostic{
-			Severity: hcl.DiagError,
-			Detail:   "Failed to generate values",
-			Summary:  fmt.Sprintf("Terraform failed to generate computed values for a mocked resource, data source, or module: %s. This is a bug in Terraform - please report it.", err),
-			Subject:  with.Range.Ptr(),
-		})
-	}
-
-	return value, diags
-}
-
 func isNull(target cty.Value) bool {
 	return target.IsNull()
 }
@@ -164,70 +83,6 @@ type ReplacementValue struct {
 	Range hcl.Range
 }
 
-func (replacement ReplacementValue) makeKnown(target, with cty.Value, path cty.Path) (cty.Value, tfdiags.Diagnostics) {
-	var diags tfdiags.Diagnostics
-
-	if with != cty.NilVal {
-		// Then we have a pre-made value to replace it with. We'll make sure it
-		// is compatible with a conversion, and then just return it in place.
-
-		if value, err := convert.Convert(with, target.Type()); err != nil {
-			diags = diags.Append(tfdiags.AttributeValue(
-				tfdiags.Error,
-				"Failed to replace target attribute",
-				fmt.Sprintf("Terraform could not replace the target type %s with the replacement value defined at %s within %s: %s.", target.Type().FriendlyName(), fmtPath(path), replacement.Range, err),
-				path))
-
-			// We still want to return a valid value here. If the conversion did
-			// not work we carry on and just create a value instead. We've made
-			// a note of the diagnostics tracking why it didn't work so the
-			// overall operation will still fail, but we won't crash later on
-			// because of an unknown value or something.
-
-		} else {
-			// Successful conversion! We can just return the new value.
-			return value, diags
-		}
-	}
-
-	// Otherwise, we'll have to generate some values.
-	// We just return zero values for most of the types. The only exceptions are
-	// objects and strings. For strings, we generate 8 random alphanumeric
-	// characters. Objects need to be valid types, so we recurse through the
-	// attributes and recursively call this function to generate values for
-	// each attribute.
-
-	switch {
-	case target.Type().IsPrimitiveType():
-		switch target.Type() {
-		case cty.String:
-			return cty.StringVal(str(8)), diags
-		case cty.Number:
-			return cty.Zero, diags
-		case cty.Bool:
-			return cty.False, diags
-		default:
-			panic(fmt.Errorf("unknown primitive type: %s", target.Type().FriendlyName()))
-		}
-	case target.Type().IsListType():
-		return cty.ListValEmpty(target.Type().ElementType()), diags
-	case target.Type().IsSetType():
-		return cty.SetValEmpty(target.Type().ElementType()), diags
-	case target.Type().IsMapType():
-		return cty.MapValEmpty(target.Type().ElementType()), diags
-	case target.Type().IsObjectType():
-		children := make(map[string]cty.Value)
-		for name, attribute := range target.Type().AttributeTypes() {
-			child, childDiags := replacement.makeKnown(cty.UnknownVal(attribute), cty.NilVal, path.GetAttr(name))
-			diags = diags.Append(childDiags)
-			children[name] = child
-		}
-		return cty.ObjectVal(children), diags
-	default:
-		panic(fmt.Errorf("unknown complex type: %s", target.Type().FriendlyName()))
-	}
-}
-
 // We can only do replacements if the replacement value is an object type.
 func (replacement ReplacementValue) validate() bool {
 	return replacement.Value == cty.NilVal || replacement.Value.Type().IsObjectType()
@@ -250,7 +105,7 @@ func (replacement ReplacementValue) validate() bool {
 //
 // TODO(liamcervante): Revisit this function, is it possible and/or easy for us
 // to support specific targeting of elements in collections?
-func (replacement ReplacementValue) getReplacementSafe(path cty.Path) (cty.Value, tfdiags.Diagnostics) {
+func (replacement ReplacementValue) getReplacementSafe(path cty.Path, opts GenerateOptions) (cty.Value, tfdiags.Diagnostics) {
 	var diags tfdiags.Diagnostics
 
 	if replacement.Value == cty.NilVal {
@@ -266,10 +121,30 @@ func (replacement ReplacementValue) getReplacementSafe(path cty.Path) (cty.Value
 	// the intermediate nested blocks and attributes that we aren't capturing
 	// within the user supplied mock values.
 	current := replacement.Value
-	for _, step := range path {
+	for ix, step := range path {
 		switch step := step.(type) {
 		case cty.GetAttrStep:
 
+			if (current.Type().IsTupleType() || current.Type().IsListType()) && current.IsKnown() && !current.IsNull() && !followsIndexStep(path, ix) {
+				// The override supplies a list of objects where the schema
+				// expects a single object: the author most likely wrote a
+				// NestingSingle block with the list syntax of a NestingList
+				// block. The attributes of genuine list, set, and map blocks
+				// are reached through an index step, so those fall through to
+				// the object-type error below instead.
+				if opts.TakeFirstForSingleBlocks && current.LengthInt() > 0 {
+					current = current.Index(cty.NumberIntVal(0))
+				} else {
+					diags = diags.Append(tfdiags.AttributeValue(
+						tfdiags.Error,
+						"Failed to replace target attribute",
+						fmt.Sprintf("Terraform expected a single object at %s within the replacement value defined at %s, but found a %s of length %d. A single nested block takes exactly one object; either remove the surrounding brackets, or set the TakeFirstForSingleBlocks generation option to use the first element.", fmtPath(currentPath), replacement.Range, current.Type().FriendlyName(), current.LengthInt()),
+						currentPath))
+
+					return cty.NilVal, diags
+				}
+			}
+
 			if !current.Type().IsObjectType() {
 				// As we're still traversing the path, we expect things to be
 				// objects at every level.
@@ -282,12 +157,22 @@ func (replacement ReplacementValue) getReplacementSafe(path cty.Path) (cty.Value
 				return cty.NilVal, diags
 			}
 
-			if !current.Type().HasAttribute(step.Name) {
-				// Then we're not providing a replacement value for this path.
-				return cty.NilVal, diags
+			name := step.Name
+			if !current.Type().HasAttribute(name) {
+				if opts.CaseInsensitiveOverrideKeys {
+					// The user may have written the key with different casing
+					// or stray whitespace, so look for a loose match.
+					name = looseAttributeName(current.Type(), step.Name)
+				}
+
+				if !current.Type().HasAttribute(name) {
+					// Then we're not providing a replacement value for this
+					// path.
+					return cty.NilVal, diags
+				}
 			}
 
-			current = current.GetAttr(step.Name)
+			current = current.GetAttr(name)
 		}
 
 		currentPath = append(currentPath, step)
@@ -296,6 +181,55 @@ func (replacement ReplacementValue) getReplacementSafe(path cty.Path) (cty.Value
 	return current, diags
 }
 
+// followsIndexStep returns true if the step at the given position in the
+// path comes immediately after an index step, which is how the attributes of
+// list, set, and map blocks are reached.
+func followsIndexStep(path cty.Path, ix int) bool {
+	if ix == 0 {
+		return false
+	}
+	_, indexed := path[ix-1].(cty.IndexStep)
+	return indexed
+}
+
+// looseAttributeName searches the attributes of the given object type for one
+// whose name matches the wanted name after normalising the case and trimming
+// any surrounding whitespace. It returns the wanted name unchanged if there is
+// no loose match, so callers can just retry their lookup with the result.
+func looseAttributeName(ty cty.Type, want string) string {
+	for name := range ty.AttributeTypes() {
+		if normalizeAttributeName(name) == normalizeAttributeName(want) {
+			return name
+		}
+	}
+	return want
+}
+
+// normalizeAttributeName is the normalisation applied by the case-insensitive
+// override key matching: lowercase, with surrounding whitespace trimmed.
+func normalizeAttributeName(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// valueAtPath walks the attribute steps of the given path through a value,
+// returning false if any step can't be followed. Index steps are not
+// supported, mirroring the way replacement values are looked up.
+func valueAtPath(value cty.Value, path cty.Path) (cty.Value, bool) {
+	current := value
+	for _, step := range path {
+		step, ok := step.(cty.GetAttrStep)
+		if !ok {
+			return cty.NilVal, false
+		}
+
+		if current.IsNull() || !current.Type().IsObjectType() || !current.Type().HasAttribute(step.Name) {
+			return cty.NilVal, false
+		}
+		current = current.GetAttr(step.Name)
+	}
+	return current, true
+}
+
 func fmtPath(path cty.Path) string {
 	var current string
 
@@ -316,14 +250,38 @@ func fmtPath(path cty.Path) string {
 	return current
 }
 
+// RandSource is the minimal source of randomness the generator needs.
+// Callers can implement it with a crypto-backed or otherwise custom source
+// via the Rand generation option; by default draws come from math/rand (or
+// testRand when a test has seeded it).
+type RandSource interface {
+	// Intn returns a non-negative random int less than n.
+	Intn(n int) int
+}
+
+// defaultSource implements RandSource over testRand when set, and the
+// global math/rand source otherwise.
+type defaultSource struct{}
+
+func (defaultSource) Intn(n int) int {
+	if testRand != nil {
+		return testRand.Intn(n)
+	}
+	return rand.Intn(n)
+}
+
+func randInt(n int) int {
+	return defaultSource{}.Intn(n)
+}
+
 func str(n int) string {
+	return strFrom(defaultSource{}, n)
+}
+
+func strFrom(source RandSource, n int) string {
 	b := make([]rune, n)
 	for i := range b {
-		if testRand != nil {
-			b[i] = chars[testRand.Intn(len(chars))]
-		} else {
-			b[i] = chars[rand.Intn(len(chars))]
-		}
+		b[i] = chars[source.Intn(len(chars))]
 	}
 	return string(b)
 }
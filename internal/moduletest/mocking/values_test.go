@@ -33,6 +33,26 @@ var (
 		},
 	}
 
+	computedNumberAttributes = map[string]*configschema.Attribute{
+		"port": {
+			Type:     cty.Number,
+			Computed: true,
+		},
+		"value": {
+			Type: cty.String,
+		},
+	}
+
+	computedBoolAttributes = map[string]*configschema.Attribute{
+		"enabled": {
+			Type:     cty.Bool,
+			Computed: true,
+		},
+		"value": {
+			Type: cty.String,
+		},
+	}
+
 	normalBlock = configschema.Block{
 		Attributes: normalAttributes,
 	}
@@ -40,6 +60,14 @@ var (
 	computedBlock = configschema.Block{
 		Attributes: computedAttributes,
 	}
+
+	computedNumberBlock = configschema.Block{
+		Attributes: computedNumberAttributes,
+	}
+
+	computedBoolBlock = configschema.Block{
+		Attributes: computedBoolAttributes,
+	}
 )
 
 func TestComputedValuesForDataSource(t *testing.T) {
@@ -112,6 +140,216 @@ func TestComputedValuesForDataSource(t *testing.T) {
 				"value": cty.StringVal("Hello, world!"),
 			}),
 		},
+		"basic_computed_number_attribute_random": {
+			target: cty.ObjectVal(map[string]cty.Value{
+				"port":  cty.NullVal(cty.Number),
+				"value": cty.StringVal("Hello, world!"),
+			}),
+			with:   cty.NilVal,
+			schema: &computedNumberBlock,
+			expected: cty.ObjectVal(map[string]cty.Value{
+				"port":  cty.NumberIntVal(793274),
+				"value": cty.StringVal("Hello, world!"),
+			}),
+		},
+		"basic_computed_number_attribute_supplied": {
+			target: cty.ObjectVal(map[string]cty.Value{
+				"port":  cty.NullVal(cty.Number),
+				"value": cty.StringVal("Hello, world!"),
+			}),
+			with: cty.ObjectVal(map[string]cty.Value{
+				"port": cty.NumberIntVal(8080),
+			}),
+			schema: &computedNumberBlock,
+			expected: cty.ObjectVal(map[string]cty.Value{
+				"port":  cty.NumberIntVal(8080),
+				"value": cty.StringVal("Hello, world!"),
+			}),
+		},
+		"basic_computed_bool_attribute_random": {
+			target: cty.ObjectVal(map[string]cty.Value{
+				"enabled": cty.NullVal(cty.Bool),
+				"value":   cty.StringVal("Hello, world!"),
+			}),
+			with:   cty.NilVal,
+			schema: &computedBoolBlock,
+			expected: cty.ObjectVal(map[string]cty.Value{
+				"enabled": cty.False,
+				"value":   cty.StringVal("Hello, world!"),
+			}),
+		},
+		"basic_computed_bool_attribute_supplied": {
+			target: cty.ObjectVal(map[string]cty.Value{
+				"enabled": cty.NullVal(cty.Bool),
+				"value":   cty.StringVal("Hello, world!"),
+			}),
+			with: cty.ObjectVal(map[string]cty.Value{
+				"enabled": cty.True,
+			}),
+			schema: &computedBoolBlock,
+			expected: cty.ObjectVal(map[string]cty.Value{
+				"enabled": cty.True,
+				"value":   cty.StringVal("Hello, world!"),
+			}),
+		},
+		"computed_object_attribute_with_collection": {
+			// An object-typed attribute whose type contains a collection:
+			// the generated object should fill the string with a random
+			// value and the collection with an empty value of its type,
+			// while the sibling attribute is preserved.
+			target: cty.ObjectVal(map[string]cty.Value{
+				"metadata": cty.NullVal(cty.Object(map[string]cty.Type{
+					"id":   cty.String,
+					"tags": cty.List(cty.String),
+				})),
+				"value": cty.StringVal("Hello, world!"),
+			}),
+			with: cty.NilVal,
+			schema: &configschema.Block{
+				Attributes: map[string]*configschema.Attribute{
+					"metadata": {
+						Type: cty.Object(map[string]cty.Type{
+							"id":   cty.String,
+							"tags": cty.List(cty.String),
+						}),
+						Computed: true,
+					},
+					"value": {
+						Type: cty.String,
+					},
+				},
+			},
+			expected: cty.ObjectVal(map[string]cty.Value{
+				"metadata": cty.ObjectVal(map[string]cty.Value{
+					"id":   cty.StringVal("ssnk9qhr"),
+					"tags": cty.ListValEmpty(cty.String),
+				}),
+				"value": cty.StringVal("Hello, world!"),
+			}),
+		},
+		"computed_list_of_maps_attribute": {
+			// Elements of a computed list(map(string)) don't have schema
+			// entries of their own, but null elements should still be filled
+			// with empty maps while known elements keep their keys.
+			target: cty.ObjectVal(map[string]cty.Value{
+				"mappings": cty.ListVal([]cty.Value{
+					cty.MapVal(map[string]cty.Value{
+						"key": cty.StringVal("value"),
+					}),
+					cty.NullVal(cty.Map(cty.String)),
+				}),
+			}),
+			with: cty.NilVal,
+			schema: &configschema.Block{
+				Attributes: map[string]*configschema.Attribute{
+					"mappings": {
+						Type:     cty.List(cty.Map(cty.String)),
+						Computed: true,
+					},
+				},
+			},
+			expected: cty.ObjectVal(map[string]cty.Value{
+				"mappings": cty.ListVal([]cty.Value{
+					cty.MapVal(map[string]cty.Value{
+						"key": cty.StringVal("value"),
+					}),
+					cty.MapValEmpty(cty.String),
+				}),
+			}),
+		},
+		"computed_map_of_lists_attribute": {
+			// The mirror image: entries of a computed map(list(string)) are
+			// lists without schema entries of their own, so null entries get
+			// empty lists and known entries are preserved.
+			target: cty.ObjectVal(map[string]cty.Value{
+				"groups": cty.MapVal(map[string]cty.Value{
+					"known": cty.ListVal([]cty.Value{
+						cty.StringVal("member"),
+					}),
+					"null": cty.NullVal(cty.List(cty.String)),
+				}),
+			}),
+			with: cty.NilVal,
+			schema: &configschema.Block{
+				Attributes: map[string]*configschema.Attribute{
+					"groups": {
+						Type:     cty.Map(cty.List(cty.String)),
+						Computed: true,
+					},
+				},
+			},
+			expected: cty.ObjectVal(map[string]cty.Value{
+				"groups": cty.MapVal(map[string]cty.Value{
+					"known": cty.ListVal([]cty.Value{
+						cty.StringVal("member"),
+					}),
+					"null": cty.ListValEmpty(cty.String),
+				}),
+			}),
+		},
+		"optional_object_attribute_present": {
+			// When an optional nested-type attribute is present, its required
+			// fields must be non-null, so a null required field gets a
+			// generated value alongside the computed one.
+			target: cty.ObjectVal(map[string]cty.Value{
+				"config": cty.ObjectVal(map[string]cty.Value{
+					"token": cty.NullVal(cty.String),
+				}),
+			}),
+			with: cty.NilVal,
+			schema: &configschema.Block{
+				Attributes: map[string]*configschema.Attribute{
+					"config": {
+						NestedType: &configschema.Object{
+							Attributes: map[string]*configschema.Attribute{
+								"token": {
+									Type:     cty.String,
+									Required: true,
+								},
+							},
+							Nesting: configschema.NestingSingle,
+						},
+						Optional: true,
+					},
+				},
+			},
+			expected: cty.ObjectVal(map[string]cty.Value{
+				"config": cty.ObjectVal(map[string]cty.Value{
+					"token": cty.StringVal("ssnk9qhr"),
+				}),
+			}),
+		},
+		"optional_object_attribute_absent": {
+			// When the optional nested-type attribute is absent, the whole
+			// object stays null rather than being materialised.
+			target: cty.ObjectVal(map[string]cty.Value{
+				"config": cty.NullVal(cty.Object(map[string]cty.Type{
+					"token": cty.String,
+				})),
+			}),
+			with: cty.NilVal,
+			schema: &configschema.Block{
+				Attributes: map[string]*configschema.Attribute{
+					"config": {
+						NestedType: &configschema.Object{
+							Attributes: map[string]*configschema.Attribute{
+								"token": {
+									Type:     cty.String,
+									Required: true,
+								},
+							},
+							Nesting: configschema.NestingSingle,
+						},
+						Optional: true,
+					},
+				},
+			},
+			expected: cty.ObjectVal(map[string]cty.Value{
+				"config": cty.NullVal(cty.Object(map[string]cty.Type{
+					"token": cty.String,
+				})),
+			}),
+		},
 		"nested_single_block_preset": {
 			target: cty.ObjectVal(map[string]cty.Value{
 				"block": cty.ObjectVal(map[string]cty.Value{
@@ -384,6 +622,195 @@ func TestComputedValuesForDataSource(t *testing.T) {
 				}),
 			}),
 		},
+		"nested_list_number_block": {
+			target: cty.ObjectVal(map[string]cty.Value{
+				"block": cty.ListVal([]cty.Value{
+					cty.ObjectVal(map[string]cty.Value{
+						"port":  cty.NullVal(cty.Number),
+						"value": cty.StringVal("one"),
+					}),
+					cty.ObjectVal(map[string]cty.Value{
+						"port":  cty.NullVal(cty.Number),
+						"value": cty.StringVal("two"),
+					}),
+				}),
+			}),
+			with: cty.NilVal,
+			schema: &configschema.Block{
+				BlockTypes: map[string]*configschema.NestedBlock{
+					"block": {
+						Block:   computedNumberBlock,
+						Nesting: configschema.NestingList,
+					},
+				},
+			},
+			expected: cty.ObjectVal(map[string]cty.Value{
+				"block": cty.ListVal([]cty.Value{
+					cty.ObjectVal(map[string]cty.Value{
+						"port":  cty.NumberIntVal(793274),
+						"value": cty.StringVal("one"),
+					}),
+					cty.ObjectVal(map[string]cty.Value{
+						"port":  cty.NumberIntVal(58514),
+						"value": cty.StringVal("two"),
+					}),
+				}),
+			}),
+		},
+		"nested_set_number_block": {
+			target: cty.ObjectVal(map[string]cty.Value{
+				"block": cty.SetVal([]cty.Value{
+					cty.ObjectVal(map[string]cty.Value{
+						"port":  cty.NullVal(cty.Number),
+						"value": cty.StringVal("one"),
+					}),
+					cty.ObjectVal(map[string]cty.Value{
+						"port":  cty.NullVal(cty.Number),
+						"value": cty.StringVal("two"),
+					}),
+				}),
+			}),
+			with: cty.NilVal,
+			schema: &configschema.Block{
+				BlockTypes: map[string]*configschema.NestedBlock{
+					"block": {
+						Block:   computedNumberBlock,
+						Nesting: configschema.NestingSet,
+					},
+				},
+			},
+			expected: cty.ObjectVal(map[string]cty.Value{
+				"block": cty.SetVal([]cty.Value{
+					cty.ObjectVal(map[string]cty.Value{
+						"port":  cty.NumberIntVal(793274),
+						"value": cty.StringVal("one"),
+					}),
+					cty.ObjectVal(map[string]cty.Value{
+						"port":  cty.NumberIntVal(58514),
+						"value": cty.StringVal("two"),
+					}),
+				}),
+			}),
+		},
+		"nested_map_number_block": {
+			target: cty.ObjectVal(map[string]cty.Value{
+				"block": cty.MapVal(map[string]cty.Value{
+					"one": cty.ObjectVal(map[string]cty.Value{
+						"port":  cty.NullVal(cty.Number),
+						"value": cty.StringVal("one"),
+					}),
+					"two": cty.ObjectVal(map[string]cty.Value{
+						"port":  cty.NullVal(cty.Number),
+						"value": cty.StringVal("two"),
+					}),
+				}),
+			}),
+			with: cty.NilVal,
+			schema: &configschema.Block{
+				BlockTypes: map[string]*configschema.NestedBlock{
+					"block": {
+						Block:   computedNumberBlock,
+						Nesting: configschema.NestingMap,
+					},
+				},
+			},
+			expected: cty.ObjectVal(map[string]cty.Value{
+				"block": cty.MapVal(map[string]cty.Value{
+					"one": cty.ObjectVal(map[string]cty.Value{
+						"port":  cty.NumberIntVal(793274),
+						"value": cty.StringVal("one"),
+					}),
+					"two": cty.ObjectVal(map[string]cty.Value{
+						"port":  cty.NumberIntVal(58514),
+						"value": cty.StringVal("two"),
+					}),
+				}),
+			}),
+		},
+		"nested_list_bool_block": {
+			// Three elements, so the seeded draws cover both outcomes of
+			// the coin flip.
+			target: cty.ObjectVal(map[string]cty.Value{
+				"block": cty.ListVal([]cty.Value{
+					cty.ObjectVal(map[string]cty.Value{
+						"enabled": cty.NullVal(cty.Bool),
+						"value":   cty.StringVal("one"),
+					}),
+					cty.ObjectVal(map[string]cty.Value{
+						"enabled": cty.NullVal(cty.Bool),
+						"value":   cty.StringVal("two"),
+					}),
+					cty.ObjectVal(map[string]cty.Value{
+						"enabled": cty.NullVal(cty.Bool),
+						"value":   cty.StringVal("three"),
+					}),
+				}),
+			}),
+			with: cty.NilVal,
+			schema: &configschema.Block{
+				BlockTypes: map[string]*configschema.NestedBlock{
+					"block": {
+						Block:   computedBoolBlock,
+						Nesting: configschema.NestingList,
+					},
+				},
+			},
+			expected: cty.ObjectVal(map[string]cty.Value{
+				"block": cty.ListVal([]cty.Value{
+					cty.ObjectVal(map[string]cty.Value{
+						"enabled": cty.False,
+						"value":   cty.StringVal("one"),
+					}),
+					cty.ObjectVal(map[string]cty.Value{
+						"enabled": cty.False,
+						"value":   cty.StringVal("two"),
+					}),
+					cty.ObjectVal(map[string]cty.Value{
+						"enabled": cty.True,
+						"value":   cty.StringVal("three"),
+					}),
+				}),
+			}),
+		},
+		"nested_map_bool_block_supplied": {
+			target: cty.ObjectVal(map[string]cty.Value{
+				"block": cty.MapVal(map[string]cty.Value{
+					"one": cty.ObjectVal(map[string]cty.Value{
+						"enabled": cty.NullVal(cty.Bool),
+						"value":   cty.StringVal("one"),
+					}),
+					"two": cty.ObjectVal(map[string]cty.Value{
+						"enabled": cty.NullVal(cty.Bool),
+						"value":   cty.StringVal("two"),
+					}),
+				}),
+			}),
+			with: cty.ObjectVal(map[string]cty.Value{
+				"block": cty.ObjectVal(map[string]cty.Value{
+					"enabled": cty.True,
+				}),
+			}),
+			schema: &configschema.Block{
+				BlockTypes: map[string]*configschema.NestedBlock{
+					"block": {
+						Block:   computedBoolBlock,
+						Nesting: configschema.NestingMap,
+					},
+				},
+			},
+			expected: cty.ObjectVal(map[string]cty.Value{
+				"block": cty.MapVal(map[string]cty.Value{
+					"one": cty.ObjectVal(map[string]cty.Value{
+						"enabled": cty.True,
+						"value":   cty.StringVal("one"),
+					}),
+					"two": cty.ObjectVal(map[string]cty.Value{
+						"enabled": cty.True,
+						"value":   cty.StringVal("two"),
+					}),
+				}),
+			}),
+		},
 		"nested_single_attribute": {
 			target: cty.ObjectVal(map[string]cty.Value{
 				"nested": cty.ObjectVal(map[string]cty.Value{
@@ -536,6 +963,31 @@ func TestComputedValuesForDataSource(t *testing.T) {
 				}),
 			}),
 		},
+		"nested_single_number_attribute": {
+			target: cty.ObjectVal(map[string]cty.Value{
+				"nested": cty.ObjectVal(map[string]cty.Value{
+					"port":  cty.NullVal(cty.Number),
+					"value": cty.StringVal("Hello, world!"),
+				}),
+			}),
+			with: cty.NilVal,
+			schema: &configschema.Block{
+				Attributes: map[string]*configschema.Attribute{
+					"nested": {
+						NestedType: &configschema.Object{
+							Attributes: computedNumberAttributes,
+							Nesting:    configschema.NestingSingle,
+						},
+					},
+				},
+			},
+			expected: cty.ObjectVal(map[string]cty.Value{
+				"nested": cty.ObjectVal(map[string]cty.Value{
+					"port":  cty.NumberIntVal(793274),
+					"value": cty.StringVal("Hello, world!"),
+				}),
+			}),
+		},
 		"invalid_replacement_path": {
 			target: cty.ObjectVal(map[string]cty.Value{
 				"id":    cty.StringVal("kj87eb9"),